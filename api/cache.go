@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/events/v1beta1"
+	networkv1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	eventslisters "k8s.io/client-go/listers/events/v1beta1"
+	networklisters "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Cache 基于 shared informer 的只读缓存, 按集群和 namespace 范围创建
+// clientSetClient 设置 Cache 后, get 以及 ResourceVersion 为 "0" 的 list 优先从缓存读取,
+// 缓存未同步或 namespace 不在范围内时直接访问 apiserver
+type Cache struct {
+	namespace string
+	factory   informers.SharedInformerFactory
+	informers []cache.SharedIndexInformer
+
+	deployments appslisters.DeploymentLister
+	pods        corelisters.PodLister
+	services    corelisters.ServiceLister
+	nodes       corelisters.NodeLister
+	ingresses   networklisters.IngressLister
+	events      eventslisters.EventLister
+
+	once      sync.Once
+	stopCh    chan struct{}
+	stopped   int32
+	lastEvent int64
+	hits      int64
+	misses    int64
+}
+
+// CacheStats 缓存状态, 用于监控
+type CacheStats struct {
+	Namespace     string        `json:"namespace"`
+	Synced        bool          `json:"synced"`
+	LastEventTime time.Time     `json:"last_event_time"`
+	Staleness     time.Duration `json:"staleness"` // 距离最近一次收到 informer 事件(含 resync)的时间
+	Hits          int64         `json:"hits"`
+	Misses        int64         `json:"misses"`
+}
+
+// NewCache 初始化缓存, namespace 为空时缓存全部 namespace; resync 为 0 时不做周期性 resync
+func NewCache(clientSet kubernetes.Interface, namespace string, resync time.Duration) *Cache {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientSet, resync, informers.WithNamespace(namespace))
+	c := &Cache{
+		namespace: namespace,
+		factory:   factory,
+		stopCh:    make(chan struct{}),
+	}
+
+	deployments := factory.Apps().V1().Deployments()
+	pods := factory.Core().V1().Pods()
+	services := factory.Core().V1().Services()
+	ingresses := factory.Networking().V1().Ingresses()
+	events := factory.Events().V1beta1().Events()
+
+	c.deployments = deployments.Lister()
+	c.pods = pods.Lister()
+	c.services = services.Lister()
+	c.ingresses = ingresses.Lister()
+	c.events = events.Lister()
+	c.informers = []cache.SharedIndexInformer{
+		deployments.Informer(),
+		pods.Informer(),
+		services.Informer(),
+		ingresses.Informer(),
+		events.Informer(),
+	}
+	// node 是集群级资源, 只有不限定 namespace 的缓存才监听, 避免 namespace 级权限的账号无法完成同步
+	if namespace == "" {
+		nodes := factory.Core().V1().Nodes()
+		c.nodes = nodes.Lister()
+		c.informers = append(c.informers, nodes.Informer())
+	}
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.touch() },
+		UpdateFunc: func(oldObj, newObj interface{}) { c.touch() },
+		DeleteFunc: func(obj interface{}) { c.touch() },
+	}
+	for _, informer := range c.informers {
+		informer.AddEventHandler(handler)
+	}
+	return c
+}
+
+// Start 启动 informer, 不等待同步完成
+func (c *Cache) Start() {
+	c.factory.Start(c.stopCh)
+}
+
+// WaitForSync 等待全部 informer 完成首次 list, ctx 取消时返回 Timeout 错误, 同步完成前查询会直接访问 apiserver
+func (c *Cache) WaitForSync(ctx context.Context) error {
+	hasSynced := make([]cache.InformerSynced, 0, len(c.informers))
+	for _, informer := range c.informers {
+		hasSynced = append(hasSynced, informer.HasSynced)
+	}
+	if !cache.WaitForCacheSync(ctx.Done(), hasSynced...) {
+		return NewError(ReasonTimeout, "Cache", c.namespace, "", ctx.Err())
+	}
+	c.touch()
+	return nil
+}
+
+// Stop 停止 informer, 之后的查询全部直接访问 apiserver
+func (c *Cache) Stop() {
+	c.once.Do(func() {
+		atomic.StoreInt32(&c.stopped, 1)
+		close(c.stopCh)
+	})
+}
+
+// HasSynced 缓存是否可用: 未停止且全部 informer 已完成首次 list
+func (c *Cache) HasSynced() bool {
+	if atomic.LoadInt32(&c.stopped) == 1 {
+		return false
+	}
+	for _, informer := range c.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Namespace 缓存的 namespace 范围, 空字符串表示全部
+func (c *Cache) Namespace() string {
+	return c.namespace
+}
+
+// Stats 返回缓存命中和过期情况
+func (c *Cache) Stats() CacheStats {
+	stats := CacheStats{
+		Namespace: c.namespace,
+		Synced:    c.HasSynced(),
+		Hits:      atomic.LoadInt64(&c.hits),
+		Misses:    atomic.LoadInt64(&c.misses),
+	}
+	if last := atomic.LoadInt64(&c.lastEvent); last > 0 {
+		stats.LastEventTime = time.Unix(0, last)
+		stats.Staleness = time.Since(stats.LastEventTime)
+	}
+	return stats
+}
+
+func (c *Cache) touch() {
+	atomic.StoreInt64(&c.lastEvent, time.Now().UnixNano())
+}
+
+// covers 缓存是否可以响应指定 namespace 的查询, 并记录命中情况
+func (c *Cache) covers(namespace string) bool {
+	if c == nil {
+		return false
+	}
+	if c.HasSynced() && (c.namespace == "" || c.namespace == namespace) {
+		atomic.AddInt64(&c.hits, 1)
+		return true
+	}
+	atomic.AddInt64(&c.misses, 1)
+	return false
+}
+
+func (c *Cache) deploymentList(namespace string, opts ListOpts) (*appsv1.DeploymentList, error) {
+	selector, err := opts.selector()
+	if err != nil {
+		return nil, NewError(ReasonInvalid, "", namespace, "", err)
+	}
+	items, err := c.deployments.Deployments(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	list := &appsv1.DeploymentList{}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *Cache) deploymentGet(namespace, name string) (*appsv1.Deployment, error) {
+	deploy, err := c.deployments.Deployments(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return deploy.DeepCopy(), nil
+}
+
+func (c *Cache) podList(namespace string, opts ListOpts) (*apiv1.PodList, error) {
+	selector, err := opts.selector()
+	if err != nil {
+		return nil, NewError(ReasonInvalid, "", namespace, "", err)
+	}
+	items, err := c.pods.Pods(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	list := &apiv1.PodList{}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *Cache) podGet(namespace, name string) (*apiv1.Pod, error) {
+	pod, err := c.pods.Pods(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return pod.DeepCopy(), nil
+}
+
+func (c *Cache) serviceGet(namespace, name string) (*apiv1.Service, error) {
+	svc, err := c.services.Services(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return svc.DeepCopy(), nil
+}
+
+func (c *Cache) ingressList(namespace string, opts ListOpts) (*networkv1.IngressList, error) {
+	selector, err := opts.selector()
+	if err != nil {
+		return nil, NewError(ReasonInvalid, "", namespace, "", err)
+	}
+	items, err := c.ingresses.Ingresses(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	list := &networkv1.IngressList{}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *Cache) eventList(namespace string, opts ListOpts) (*v1beta1.EventList, error) {
+	selector, err := opts.selector()
+	if err != nil {
+		return nil, NewError(ReasonInvalid, "", namespace, "", err)
+	}
+	items, err := c.events.Events(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	list := &v1beta1.EventList{}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	return list, nil
+}
+
+func (c *Cache) nodeList(opts ListOpts) (*apiv1.NodeList, error) {
+	selector, err := opts.selector()
+	if err != nil {
+		return nil, NewError(ReasonInvalid, "", "", "", err)
+	}
+	items, err := c.nodes.List(selector)
+	if err != nil {
+		return nil, err
+	}
+	list := &apiv1.NodeList{}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.DeepCopy())
+	}
+	return list, nil
+}
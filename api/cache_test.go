@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCache(t *testing.T) {
+	c := NewFakeClientSet(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "cn-online"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "cn-online"}},
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx-0", Namespace: "cn-online", Labels: map[string]string{"app": "nginx"}}},
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "redis-0", Namespace: "cn-online", Labels: map[string]string{"app": "redis"}}},
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx-0", Namespace: "cn-test", Labels: map[string]string{"app": "nginx"}}},
+	)
+	cache := NewCache(c.ClientSet, "cn-online", 0)
+	defer cache.Stop()
+
+	// 未同步时直接访问 apiserver
+	c.Cache = cache
+	_, err := c.DeploymentList("cn-online", ListOpts{ResourceVersion: "0"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, cache.Stats().Misses)
+
+	cache.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cache.WaitForSync(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 同步后 ResourceVersion 为 "0" 的 list 以及 get 不再访问 apiserver
+	var listed int
+	c.ClientSet.(*fake.Clientset).PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetVerb() == "list" || action.GetVerb() == "get" {
+			listed++
+		}
+		return false, nil, nil
+	})
+
+	deploys, err := c.DeploymentListFormat("cn-online", ListOpts{ResourceVersion: "0"})
+	assert.NoError(t, err)
+	assert.Len(t, deploys, 2)
+	pods, err := c.Pods("cn-online", map[string]string{"app": "nginx"}, ListOpts{ResourceVersion: "0"})
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 1)
+	_, err = c.DeploymentGet("cn-online", "mysql")
+	assert.True(t, IsNotFound(err))
+	assert.EqualValues(t, 0, listed)
+
+	// 范围外的 namespace 访问 apiserver
+	pods, err = c.PodList("cn-test", ListOpts{ResourceVersion: "0"})
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 1)
+	assert.EqualValues(t, 1, listed)
+
+	stats := cache.Stats()
+	assert.True(t, stats.Synced)
+	assert.EqualValues(t, 3, stats.Hits)
+	assert.EqualValues(t, 2, stats.Misses)
+	assert.False(t, stats.LastEventTime.IsZero())
+
+	cache.Stop()
+	assert.False(t, cache.HasSynced())
+}
+
+func TestCacheConsistentList(t *testing.T) {
+	c := NewFakeClientSet(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "cn-online"}})
+	// informer 收不到 watch 事件, 模拟缓存落后于 apiserver
+	c.ClientSet.(*fake.Clientset).PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		return true, watch.NewFake(), nil
+	})
+	cache := NewCache(c.ClientSet, "cn-online", 0)
+	defer cache.Stop()
+	cache.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cache.WaitForSync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c.Cache = cache
+
+	_, err := c.ClientSet.AppsV1().Deployments("cn-online").Create(ctx,
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "cn-online"}}, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	deploys, err := c.DeploymentList("cn-online")
+	assert.NoError(t, err)
+	assert.Len(t, deploys.Items, 2)
+	deploys, err = c.DeploymentList("cn-online", ListOpts{ResourceVersion: "0"})
+	assert.NoError(t, err)
+	assert.Len(t, deploys.Items, 1)
+}
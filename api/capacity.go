@@ -0,0 +1,271 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ResourceUsage pod requests/limits 汇总
+type ResourceUsage struct {
+	CpuRequests resource.Quantity `json:"cpu_requests"`
+	CpuLimits   resource.Quantity `json:"cpu_limits"`
+	MemRequests resource.Quantity `json:"mem_requests"`
+	MemLimits   resource.Quantity `json:"mem_limits"`
+	Pods        int64             `json:"pods"`
+}
+
+func (u *ResourceUsage) add(requests, limits apiv1.ResourceList) {
+	u.CpuRequests.Add(*requests.Cpu())
+	u.CpuLimits.Add(*limits.Cpu())
+	u.MemRequests.Add(*requests.Memory())
+	u.MemLimits.Add(*limits.Memory())
+	u.Pods++
+}
+
+// NodeCapacity 单个 node 的分配情况
+type NodeCapacity struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Roles       string `json:"roles"`
+	Schedulable bool   `json:"schedulable"` // Ready 且未被 cordon
+	ResourceUsage
+	CpuAllocatable    resource.Quantity `json:"cpu_allocatable"`
+	MemAllocatable    resource.Quantity `json:"mem_allocatable"`
+	PodsAllocatable   int64             `json:"pods_allocatable"`
+	CpuRequestPercent int64             `json:"cpu_request_percent"`
+	CpuLimitPercent   int64             `json:"cpu_limit_percent"`
+	MemRequestPercent int64             `json:"mem_request_percent"`
+	MemLimitPercent   int64             `json:"mem_limit_percent"`
+	// Overcommitted requests 或 limits 超过 allocatable, limits 超出时节点资源紧张可能触发 OOM/CPU 限流
+	Overcommitted bool `json:"overcommitted"`
+
+	node      *apiv1.Node
+	requested apiv1.ResourceList
+}
+
+func (n *NodeCapacity) calculate() {
+	n.CpuRequestPercent = quantityPercent(&n.CpuRequests, &n.CpuAllocatable, true)
+	n.CpuLimitPercent = quantityPercent(&n.CpuLimits, &n.CpuAllocatable, true)
+	n.MemRequestPercent = quantityPercent(&n.MemRequests, &n.MemAllocatable, false)
+	n.MemLimitPercent = quantityPercent(&n.MemLimits, &n.MemAllocatable, false)
+	n.Overcommitted = n.CpuRequestPercent > 100 || n.CpuLimitPercent > 100 ||
+		n.MemRequestPercent > 100 || n.MemLimitPercent > 100
+}
+
+// NamespaceCapacity 单个 namespace 的 requests/limits, 包含尚未调度的 Pending pod
+type NamespaceCapacity struct {
+	Namespace string `json:"namespace"`
+	ResourceUsage
+}
+
+// ClusterCapacity 集群容量报告, 可直接序列化为 JSON 或通过 WriteTable 输出表格
+type ClusterCapacity struct {
+	Total      NodeCapacity        `json:"total"` // 所有 node 汇总
+	Nodes      []NodeCapacity      `json:"nodes"`
+	Namespaces []NamespaceCapacity `json:"namespaces"`
+}
+
+// CapacityFit 扩容评估结果
+type CapacityFit struct {
+	Replicas  int                `json:"replicas"`  // 需要新增的副本数
+	Available int                `json:"available"` // 按剩余 allocatable 还能调度的副本数
+	Fits      bool               `json:"fits"`
+	Requests  apiv1.ResourceList `json:"requests"` // 单个副本的 requests
+	Nodes     map[string]int     `json:"nodes"`    // 每个 node 可容纳的副本数
+}
+
+// CapacityReport 按 node 和 namespace 汇总 pod requests/limits 与 allocatable 的对比
+func (c *clientSetClient) CapacityReport() (*ClusterCapacity, error) {
+	return c.CapacityReportContext(c.defaultContext())
+}
+
+func (c *clientSetClient) CapacityReportContext(ctx context.Context) (*ClusterCapacity, error) {
+	nodeList, err := c.nodeList(ctx, ListOpts{})
+	if err != nil {
+		return nil, err
+	}
+	podList, err := c.PodListContext(ctx, apiv1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ClusterCapacity{Total: NodeCapacity{Name: "total"}}
+	nodes := make(map[string]*NodeCapacity, len(nodeList.Items))
+	report.Nodes = make([]NodeCapacity, len(nodeList.Items))
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		status := nodeStatus(node)
+		report.Nodes[i] = NodeCapacity{
+			Name:            node.Name,
+			Status:          status,
+			Roles:           nodeRoles(node),
+			Schedulable:     status == "Ready",
+			CpuAllocatable:  node.Status.Allocatable.Cpu().DeepCopy(),
+			MemAllocatable:  node.Status.Allocatable.Memory().DeepCopy(),
+			PodsAllocatable: node.Status.Allocatable.Pods().Value(),
+			node:            node,
+			requested:       apiv1.ResourceList{},
+		}
+		nodes[node.Name] = &report.Nodes[i]
+	}
+
+	namespaces := map[string]*NamespaceCapacity{}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if podFinished(pod) {
+			continue
+		}
+		requests, limits := podRequests(pod), podLimits(pod)
+		ns, ok := namespaces[pod.Namespace]
+		if !ok {
+			ns = &NamespaceCapacity{Namespace: pod.Namespace}
+			namespaces[pod.Namespace] = ns
+		}
+		ns.add(requests, limits)
+		if node, ok := nodes[pod.Spec.NodeName]; ok {
+			node.add(requests, limits)
+			addResourceList(node.requested, requests)
+		}
+	}
+
+	total := &report.Total
+	for i := range report.Nodes {
+		node := &report.Nodes[i]
+		node.calculate()
+		total.CpuRequests.Add(node.CpuRequests)
+		total.CpuLimits.Add(node.CpuLimits)
+		total.MemRequests.Add(node.MemRequests)
+		total.MemLimits.Add(node.MemLimits)
+		total.Pods += node.Pods
+		total.CpuAllocatable.Add(node.CpuAllocatable)
+		total.MemAllocatable.Add(node.MemAllocatable)
+		total.PodsAllocatable += node.PodsAllocatable
+		total.Overcommitted = total.Overcommitted || node.Overcommitted
+	}
+	overcommitted := total.Overcommitted
+	total.calculate()
+	total.Overcommitted = overcommitted
+
+	for _, ns := range namespaces {
+		report.Namespaces = append(report.Namespaces, *ns)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+	})
+	return report, nil
+}
+
+// DeploymentFit 评估集群剩余资源能否再调度 replicas 个 deployment 副本
+func (c *clientSetClient) DeploymentFit(namespace, name string, replicas int) (CapacityFit, error) {
+	return c.DeploymentFitContext(c.defaultContext(), namespace, name, replicas)
+}
+
+func (c *clientSetClient) DeploymentFitContext(ctx context.Context, namespace, name string, replicas int) (fit CapacityFit, err error) {
+	deploy, err := c.DeploymentGetContext(ctx, namespace, name)
+	if err != nil {
+		return
+	}
+	report, err := c.CapacityReportContext(ctx)
+	if err != nil {
+		return
+	}
+	return report.Fit(&deploy.Spec.Template.Spec, replicas), nil
+}
+
+// Fit 按 requests 估算还能调度多少个 spec 对应的 pod, 只考虑可调度 node 的剩余 allocatable、
+// nodeSelector 和 NoSchedule/NoExecute 污点, 不考虑亲和性和拓扑分布约束
+func (r *ClusterCapacity) Fit(spec *apiv1.PodSpec, replicas int) CapacityFit {
+	requests := podSpecResources(spec, func(r apiv1.ResourceRequirements) apiv1.ResourceList { return r.Requests })
+	fit := CapacityFit{Replicas: replicas, Requests: requests, Nodes: map[string]int{}}
+	selector := labels.SelectorFromSet(spec.NodeSelector)
+	for i := range r.Nodes {
+		node := &r.Nodes[i]
+		if !node.Schedulable || node.node == nil || !selector.Matches(labels.Set(node.node.Labels)) ||
+			!toleratesTaints(spec.Tolerations, node.node.Spec.Taints) {
+			continue
+		}
+		if n := nodeFitCount(node, requests); n > 0 {
+			fit.Nodes[node.Name] = int(n)
+			fit.Available += int(n)
+		}
+	}
+	fit.Fits = fit.Available >= replicas
+	return fit
+}
+
+// nodeFitCount node 剩余资源可容纳的 pod 数量
+func nodeFitCount(node *NodeCapacity, requests apiv1.ResourceList) int64 {
+	count := node.PodsAllocatable - node.Pods
+	for name, quantity := range requests {
+		if quantity.IsZero() {
+			continue
+		}
+		allocatable := node.node.Status.Allocatable[name]
+		free := allocatable.DeepCopy()
+		free.Sub(node.requested[name])
+		var n int64
+		if free.Sign() > 0 {
+			n = free.MilliValue() / quantity.MilliValue()
+		}
+		if n < count {
+			count = n
+		}
+	}
+	if count < 0 {
+		return 0
+	}
+	return count
+}
+
+func toleratesTaints(tolerations []apiv1.Toleration, taints []apiv1.Taint) bool {
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == apiv1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTable 以表格形式输出 node 与 namespace 的分配情况
+func (r *ClusterCapacity) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tSTATUS\tROLES\tCPU REQUESTS\tCPU LIMITS\tMEMORY REQUESTS\tMEMORY LIMITS\tPODS\tOVERCOMMITTED")
+	for _, node := range append(r.Nodes, r.Total) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%d/%d\t%t\n",
+			node.Name, node.Status, node.Roles,
+			usageColumn(node.CpuRequests, node.CpuAllocatable, node.CpuRequestPercent),
+			usageColumn(node.CpuLimits, node.CpuAllocatable, node.CpuLimitPercent),
+			usageColumn(node.MemRequests, node.MemAllocatable, node.MemRequestPercent),
+			usageColumn(node.MemLimits, node.MemAllocatable, node.MemLimitPercent),
+			node.Pods, node.PodsAllocatable, node.Overcommitted)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "NAMESPACE\tCPU REQUESTS\tCPU LIMITS\tMEMORY REQUESTS\tMEMORY LIMITS\tPODS")
+	for _, ns := range r.Namespaces {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\n", ns.Namespace,
+			ns.CpuRequests.String(), ns.CpuLimits.String(), ns.MemRequests.String(), ns.MemLimits.String(), ns.Pods)
+	}
+	return tw.Flush()
+}
+
+func usageColumn(used, allocatable resource.Quantity, percent int64) string {
+	return fmt.Sprintf("%s/%s (%d%%)", used.String(), allocatable.String(), percent)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCapacityReport(t *testing.T) {
+	resources := func(cpu, mem string) apiv1.ResourceList {
+		return apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse(cpu), apiv1.ResourceMemory: resource.MustParse(mem)}
+	}
+	node := func(name string, taints ...apiv1.Taint) *apiv1.Node {
+		allocatable := resources("4", "8Gi")
+		allocatable[apiv1.ResourcePods] = resource.MustParse("110")
+		return &apiv1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       apiv1.NodeSpec{Taints: taints},
+			Status: apiv1.NodeStatus{
+				Conditions:  []apiv1.NodeCondition{{Type: apiv1.NodeReady, Status: apiv1.ConditionTrue}},
+				Allocatable: allocatable,
+			},
+		}
+	}
+	pod := func(namespace, name, nodeName string, requests, limits apiv1.ResourceList) *apiv1.Pod {
+		return &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: apiv1.PodSpec{NodeName: nodeName, Containers: []apiv1.Container{{
+				Name:      name,
+				Resources: apiv1.ResourceRequirements{Requests: requests, Limits: limits},
+			}}},
+			Status: apiv1.PodStatus{Phase: apiv1.PodRunning},
+		}
+	}
+	template := apiv1.PodTemplateSpec{Spec: apiv1.PodSpec{Containers: []apiv1.Container{{
+		Name:      "api",
+		Resources: apiv1.ResourceRequirements{Requests: resources("1", "1Gi")},
+	}}}}
+	c := NewFakeClientSet(
+		node("node-1"),
+		node("node-2"),
+		node("gpu-1", apiv1.Taint{Key: "gpu", Effect: apiv1.TaintEffectNoSchedule}),
+		pod("cn-online", "mysql-0", "node-1", resources("2", "4Gi"), resources("4", "16Gi")),
+		pod("cn-online", "api-1", "node-2", resources("1", "1Gi"), resources("1", "1Gi")),
+		pod("cn-offline", "pending", "", resources("1", "1Gi"), nil),
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "cn-online"},
+			Spec:       appsv1.DeploymentSpec{Template: template},
+		},
+	)
+
+	report, err := c.CapacityReport()
+	assert.NoError(t, err)
+	assert.Len(t, report.Nodes, 3)
+	node1 := report.Nodes[1]
+	assert.EqualValues(t, "node-1", node1.Name)
+	assert.EqualValues(t, 50, node1.CpuRequestPercent)
+	assert.EqualValues(t, 200, node1.MemLimitPercent)
+	assert.True(t, node1.Overcommitted)
+	assert.False(t, report.Nodes[2].Overcommitted)
+	assert.True(t, report.Total.Overcommitted)
+	assert.EqualValues(t, 2, report.Total.Pods)
+	assert.EqualValues(t, "3", report.Total.CpuRequests.String())
+	assert.EqualValues(t, "12", report.Total.CpuAllocatable.String())
+
+	assert.Len(t, report.Namespaces, 2)
+	assert.EqualValues(t, "cn-offline", report.Namespaces[0].Namespace)
+	assert.EqualValues(t, 1, report.Namespaces[0].Pods)
+	assert.EqualValues(t, "5Gi", report.Namespaces[1].MemRequests.String())
+
+	// gpu-1 有污点不可调度, node-1 剩余 2 核, node-2 剩余 3 核
+	fit, err := c.DeploymentFit("cn-online", "api", 5)
+	assert.NoError(t, err)
+	assert.True(t, fit.Fits)
+	assert.EqualValues(t, 5, fit.Available)
+	assert.EqualValues(t, map[string]int{"node-1": 2, "node-2": 3}, fit.Nodes)
+
+	template.Spec.Tolerations = []apiv1.Toleration{{Key: "gpu", Operator: apiv1.TolerationOpExists}}
+	fit = report.Fit(&template.Spec, 20)
+	assert.False(t, fit.Fits)
+	assert.EqualValues(t, 9, fit.Available)
+
+	_, err = json.Marshal(report)
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteTable(&buf))
+	assert.Contains(t, buf.String(), "2/4 (50%)")
+}
@@ -2,16 +2,44 @@ package api
 
 import (
 	"errors"
+	"path/filepath"
+	"sort"
 
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
 
+var (
+	// ErrKubeConfigParse kubeConfig 内容无法解析, 例如 yaml 格式错误
+	ErrKubeConfigParse = errors.New("KubeConfig内容错误")
+	// ErrKubeConfigInvalid kubeConfig 可以解析, 但 context/cluster/user 配置缺失或无效
+	ErrKubeConfigInvalid = errors.New("KubeConfig配置无效")
+)
+
+// KubeConfigError 构建 rest.Config 失败时返回, 保留原始错误
+type KubeConfigError struct {
+	Reason error
+	Err    error
+}
+
+func (e *KubeConfigError) Error() string {
+	return e.Reason.Error() + ": " + e.Err.Error()
+}
+
+func (e *KubeConfigError) Unwrap() error {
+	return e.Err
+}
+
+func (e *KubeConfigError) Is(target error) bool {
+	return target == e.Reason
+}
+
 // GetK8sClient 获取k8s clientSet Client
-func GetK8sClient(k8sConf string) (*kubernetes.Clientset, error) {
-	cfg, err := initClient(k8sConf)
+func GetK8sClient(k8sConf string, opts ...Option) (*kubernetes.Clientset, error) {
+	cfg, err := initClient(k8sConf, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -20,8 +48,8 @@ func GetK8sClient(k8sConf string) (*kubernetes.Clientset, error) {
 }
 
 // GetK8sDiscoveryClient 获取k8s discovery Client
-func GetK8sDiscoveryClient(k8sConf string) (dynamic.Interface, error) {
-	cfg, err := initClient(k8sConf)
+func GetK8sDiscoveryClient(k8sConf string, opts ...Option) (dynamic.Interface, error) {
+	cfg, err := initClient(k8sConf, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -29,12 +57,94 @@ func GetK8sDiscoveryClient(k8sConf string) (dynamic.Interface, error) {
 	return dynamic.NewForConfig(cfg)
 }
 
-func initClient(k8sConf string) (*rest.Config, error) {
-	config, err := clientcmd.RESTConfigFromKubeConfig([]byte(k8sConf))
-	// skips the validity check for the server's certificate. This will make your HTTPS connections insecure.
-	// config.TLSClientConfig.Insecure = true
+// InClusterConfig 使用 Pod 内的 ServiceAccount 构建 rest.Config
+func InClusterConfig() (*rest.Config, error) {
+	return rest.InClusterConfig()
+}
+
+// KubeConfigFromFile 通过 kubeConfig 文件构建 rest.Config
+// path 为空时与 kubectl 一致, 合并 KUBECONFIG 环境变量中的文件或使用 ~/.kube/config;
+// path 可以是以路径分隔符连接的多个文件; contextName 为空时使用 current-context
+func KubeConfigFromFile(path, contextName string) (*rest.Config, error) {
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules(path), &clientcmd.ConfigOverrides{CurrentContext: contextName})
+	if _, err := clientConfig.RawConfig(); err != nil {
+		return nil, &KubeConfigError{Reason: ErrKubeConfigParse, Err: err}
+	}
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, &KubeConfigError{Reason: ErrKubeConfigInvalid, Err: err}
+	}
+	return config, nil
+}
+
+// KubeConfigWithContext 通过 kubeConfig 内容和指定的 context 构建 rest.Config, contextName 为空时使用 current-context
+func KubeConfigWithContext(k8sConf, contextName string) (*rest.Config, error) {
+	raw, err := clientcmd.Load([]byte(k8sConf))
+	if err != nil {
+		return nil, &KubeConfigError{Reason: ErrKubeConfigParse, Err: err}
+	}
+	clientConfig := clientcmd.NewNonInteractiveClientConfig(*raw, contextName, &clientcmd.ConfigOverrides{}, nil)
+	config, err := clientConfig.ClientConfig()
 	if err != nil {
-		return nil, errors.New("KubeConfig内容错误")
+		return nil, &KubeConfigError{Reason: ErrKubeConfigInvalid, Err: err}
 	}
 	return config, nil
 }
+
+// ListContexts 列出 kubeConfig 内容中的全部 context
+func ListContexts(k8sConf string) ([]KubeContext, error) {
+	raw, err := clientcmd.Load([]byte(k8sConf))
+	if err != nil {
+		return nil, &KubeConfigError{Reason: ErrKubeConfigParse, Err: err}
+	}
+	return contexts(raw), nil
+}
+
+// ListContextsFromFile 列出 kubeConfig 文件中的全部 context, path 规则同 KubeConfigFromFile
+func ListContextsFromFile(path string) ([]KubeContext, error) {
+	raw, err := loadingRules(path).Load()
+	if err != nil {
+		return nil, &KubeConfigError{Reason: ErrKubeConfigParse, Err: err}
+	}
+	return contexts(raw), nil
+}
+
+func initClient(k8sConf string, opts ...Option) (*rest.Config, error) {
+	config, err := KubeConfigWithContext(k8sConf, "")
+	if err != nil {
+		return nil, err
+	}
+	return applyOptions(config, opts...)
+}
+
+func loadingRules(path string) *clientcmd.ClientConfigLoadingRules {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if path == "" {
+		return rules
+	}
+	paths := filepath.SplitList(path)
+	if len(paths) == 1 {
+		rules.ExplicitPath = path
+	} else {
+		rules.Precedence = paths
+	}
+	return rules
+}
+
+func contexts(raw *clientcmdapi.Config) []KubeContext {
+	items := make([]KubeContext, 0, len(raw.Contexts))
+	for name, ctx := range raw.Contexts {
+		items = append(items, KubeContext{
+			Name:      name,
+			Cluster:   ctx.Cluster,
+			User:      ctx.AuthInfo,
+			Namespace: ctx.Namespace,
+			Current:   name == raw.CurrentContext,
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
@@ -1,13 +1,11 @@
 package api
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"sort"
 	"strings"
 	"time"
 
@@ -30,17 +28,35 @@ import (
 )
 
 type clientSetClient struct {
-	ClientSet  *kubernetes.Clientset
+	ClientSet  kubernetes.Interface
 	KubeConfig *rest.Config
+	Cluster    string        // 集群名称, 仅用于错误信息
+	Cache      *Cache        // 可选的 informer 缓存, 未同步时直接访问 apiserver
+	Metrics    MetricsSource // pod/node 实时用量来源, 为 nil 时格式化数据不包含用量
 }
 
-func NewClientSet(kubeConfig string) (cs *clientSetClient, err error) {
-	c, err := GetK8sClient(kubeConfig)
+func NewClientSet(kubeConfig string, opts ...Option) (cs *clientSetClient, err error) {
+	cfg, err := initClient(kubeConfig, opts...)
 	if err != nil {
 		return
 	}
+	return NewClientSetFromConfig(cfg)
+}
+
+// NewClientSetFromConfig 通过 rest.Config 初始化 clientSet client
+func NewClientSetFromConfig(cfg *rest.Config, opts ...Option) (*clientSetClient, error) {
+	cfg, err := applyOptions(cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
 	return &clientSetClient{
-		ClientSet: c,
+		ClientSet:  c,
+		KubeConfig: cfg,
+		Metrics:    newRESTMetricsSource(c.Discovery().RESTClient()),
 	}, nil
 }
 
@@ -48,47 +64,83 @@ func (c *clientSetClient) defaultContext() context.Context {
 	return context.TODO()
 }
 
+func (c *clientSetClient) wrapError(err error, kind, namespace, name string) error {
+	return wrapError(err, c.Cluster, kind, namespace, name)
+}
+
 // DeploymentCreate 创建 deployment
 func (c *clientSetClient) DeploymentCreate(namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error) {
-	return c.ClientSet.AppsV1().Deployments(namespace).Create(c.defaultContext(), deployment, metav1.CreateOptions{})
+	return c.DeploymentCreateContext(c.defaultContext(), namespace, deployment)
+}
+
+func (c *clientSetClient) DeploymentCreateContext(ctx context.Context, namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error) {
+	deploy, err := c.ClientSet.AppsV1().Deployments(namespace).Create(ctx, deployment, metav1.CreateOptions{})
+	return deploy, c.wrapError(err, "Deployment", namespace, deployment.Name)
 }
 
 // DeploymentList 获取 deployment
-func (c *clientSetClient) DeploymentList(namespace string) (*appsv1.DeploymentList, error) {
-	return c.ClientSet.AppsV1().Deployments(namespace).List(c.defaultContext(), metav1.ListOptions{})
+func (c *clientSetClient) DeploymentList(namespace string, opts ...ListOpts) (*appsv1.DeploymentList, error) {
+	return c.DeploymentListContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) DeploymentListContext(ctx context.Context, namespace string, opts ...ListOpts) (*appsv1.DeploymentList, error) {
+	o := listOpts(opts)
+	if o.cacheable() && c.Cache.covers(namespace) {
+		list, err := c.Cache.deploymentList(namespace, o)
+		return list, c.wrapError(err, "Deployment", namespace, "")
+	}
+	list, err := c.ClientSet.AppsV1().Deployments(namespace).List(ctx, o.ListOptions())
+	return list, c.wrapError(err, "Deployment", namespace, "")
 }
 
 // DeploymentListFormat 获取 deployment 格式化后的数据
-func (c *clientSetClient) DeploymentListFormat(namespace string) (deploymentResources []DeploymentInfo, err error) {
-	dm, err := c.DeploymentList(namespace)
+func (c *clientSetClient) DeploymentListFormat(namespace string, opts ...ListOpts) (deploymentResources []DeploymentInfo, err error) {
+	return c.DeploymentListFormatContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) DeploymentListFormatContext(ctx context.Context, namespace string, opts ...ListOpts) (deploymentResources []DeploymentInfo, err error) {
+	dm, err := c.DeploymentListContext(ctx, namespace, opts...)
 	if err != nil {
 		return
 	}
-	for _, deploy := range dm.Items {
-		dmInfo, err := c.DeploymentGetFormat(namespace, deploy.Name)
-		if err != nil {
-			return deploymentResources, err
-		}
-		deploymentResources = append(deploymentResources, dmInfo)
+	for i := range dm.Items {
+		deploymentResources = append(deploymentResources, deploymentInfo(&dm.Items[i]))
 	}
 	return
 }
 
 // DeploymentGet 查询单个 deployment 原生数据
 func (c *clientSetClient) DeploymentGet(namespace string, name string) (*appsv1.Deployment, error) {
-	return c.ClientSet.AppsV1().Deployments(namespace).Get(c.defaultContext(), name, metav1.GetOptions{})
+	return c.DeploymentGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DeploymentGetContext(ctx context.Context, namespace string, name string) (*appsv1.Deployment, error) {
+	if c.Cache.covers(namespace) {
+		deploy, err := c.Cache.deploymentGet(namespace, name)
+		return deploy, c.wrapError(err, "Deployment", namespace, name)
+	}
+	deploy, err := c.ClientSet.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	return deploy, c.wrapError(err, "Deployment", namespace, name)
 }
 
 // DeploymentGetFormat 查询单个 deployment 格式化数据
 func (c *clientSetClient) DeploymentGetFormat(namespace string, name string) (detail DeploymentInfo, err error) {
-	deploy, err := c.DeploymentGet(namespace, name)
+	return c.DeploymentGetFormatContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DeploymentGetFormatContext(ctx context.Context, namespace string, name string) (detail DeploymentInfo, err error) {
+	deploy, err := c.DeploymentGetContext(ctx, namespace, name)
 	if err != nil {
 		return
 	}
+	return deploymentInfo(deploy), nil
+}
+
+func deploymentInfo(deploy *appsv1.Deployment) DeploymentInfo {
 	createTime := deploy.ObjectMeta.CreationTimestamp.Format(common.SecLocalTimeFormat)
 	currentTime := time.Now().Format(common.SecLocalTimeFormat)
 	subTime := common.SubTime(createTime, currentTime)
-	detail = DeploymentInfo{
+	return DeploymentInfo{
 		Name:                deploy.Name,
 		Age:                 subTime,
 		UnavailableReplicas: deploy.Status.UnavailableReplicas, // 不可用副本数
@@ -97,34 +149,45 @@ func (c *clientSetClient) DeploymentGetFormat(namespace string, name string) (de
 		AvailableReplicas:   deploy.Status.AvailableReplicas,   // 可用副本数
 		Phase:               "Healthy",
 	}
-	return
 }
 
-func (c *clientSetClient) StatefulSetList(namespace string) (*appsv1.StatefulSetList, error) {
-	return c.ClientSet.AppsV1().StatefulSets(namespace).List(c.defaultContext(), metav1.ListOptions{})
+func (c *clientSetClient) StatefulSetList(namespace string, opts ...ListOpts) (*appsv1.StatefulSetList, error) {
+	return c.StatefulSetListContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) StatefulSetListContext(ctx context.Context, namespace string, opts ...ListOpts) (*appsv1.StatefulSetList, error) {
+	list, err := c.ClientSet.AppsV1().StatefulSets(namespace).List(ctx, listOpts(opts).ListOptions())
+	return list, c.wrapError(err, "StatefulSet", namespace, "")
 }
 
-func (c *clientSetClient) DaemonSetList(namespace string) (*appsv1.DaemonSetList, error) {
-	return c.ClientSet.AppsV1().DaemonSets(namespace).List(c.defaultContext(), metav1.ListOptions{})
+func (c *clientSetClient) DaemonSetList(namespace string, opts ...ListOpts) (*appsv1.DaemonSetList, error) {
+	return c.DaemonSetListContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) DaemonSetListContext(ctx context.Context, namespace string, opts ...ListOpts) (*appsv1.DaemonSetList, error) {
+	list, err := c.ClientSet.AppsV1().DaemonSets(namespace).List(ctx, listOpts(opts).ListOptions())
+	return list, c.wrapError(err, "DaemonSet", namespace, "")
 }
 
 // PodEventsGet 解析 pod/event 的详细信息
 func (c *clientSetClient) PodEventsGet(namespace string, name string) (podEvents []PodInfo, err error) {
+	return c.PodEventsGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) PodEventsGetContext(ctx context.Context, namespace string, name string) (podEvents []PodInfo, err error) {
 	labelSelector := map[string]string{"app": name}
-	podList, err := c.Pods(namespace, labelSelector)
+	podList, err := c.PodsContext(ctx, namespace, labelSelector)
 	if err != nil {
 		return
 	}
-	for _, pod := range podList.Items {
-		podInfo, err := c.PodDetail(namespace, pod.Name)
-		if err != nil {
-			return podEvents, err
-		}
-
-		eventList, err := c.EventsList(namespace)
-		if err != nil {
-			return podEvents, err
-		}
+	eventList, err := c.EventsListContext(ctx, namespace)
+	if err != nil {
+		return
+	}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		// 直接使用 list 返回的 pod, 避免逐个查询 pod 和 metrics
+		info := podInfo(pod, nil)
 		for _, item := range eventList.Items {
 			if item.Regarding.Name == pod.Name {
 				e := &PodEvent{
@@ -132,23 +195,25 @@ func (c *clientSetClient) PodEventsGet(namespace string, name string) (podEvents
 					Message: item.Reason,
 					Note:    item.Note,
 				}
-				podInfo.Events = append(podInfo.Events, e)
+				info.Events = append(info.Events, e)
 			}
 		}
-		podEvents = append(podEvents, podInfo)
+		podEvents = append(podEvents, info)
 	}
 	return
 }
 
 func (c *clientSetClient) DeploymentUpdate(namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error) {
-	return c.ClientSet.AppsV1().Deployments(namespace).Update(c.defaultContext(), deployment, metav1.UpdateOptions{})
+	return c.DeploymentUpdateContext(c.defaultContext(), namespace, deployment)
+}
+
+func (c *clientSetClient) DeploymentUpdateContext(ctx context.Context, namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error) {
+	deploy, err := c.ClientSet.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+	return deploy, c.wrapError(err, "Deployment", namespace, deployment.Name)
 }
 
 func (c *clientSetClient) DeploymentDelete(ns string, name string) error {
-	deletePolicy := metav1.DeletePropagationForeground
-	return c.DeploymentDeleteWithOption(ns, name, c.defaultContext(), metav1.DeleteOptions{
-		PropagationPolicy: &deletePolicy,
-	})
+	return c.DeploymentDeleteContext(c.defaultContext(), ns, name)
 }
 
 func (c *clientSetClient) DeploymentDeleteContext(ctx context.Context, ns string, name string) error {
@@ -160,148 +225,119 @@ func (c *clientSetClient) DeploymentDeleteContext(ctx context.Context, ns string
 
 func (c *clientSetClient) DeploymentDeleteWithOption(ns string, name string, ctx context.Context, opts metav1.DeleteOptions) error {
 	deploymentClient := c.ClientSet.AppsV1().Deployments(ns)
-	return deploymentClient.Delete(ctx, name, opts)
+	return c.wrapError(deploymentClient.Delete(ctx, name, opts), "Deployment", ns, name)
 }
 
 func (c *clientSetClient) DeploymentPods(ns string, name string) (*apiv1.PodList, error) {
-	deployment, err := c.DeploymentGet(ns, name)
+	return c.DeploymentPodsContext(c.defaultContext(), ns, name)
+}
+
+func (c *clientSetClient) DeploymentPodsContext(ctx context.Context, ns string, name string) (*apiv1.PodList, error) {
+	deployment, err := c.DeploymentGetContext(ctx, ns, name)
 	if err != nil {
 		return nil, err
 	}
 	opts := metav1.ListOptions{}
 	opts.LabelSelector = labels.FormatLabels(deployment.Spec.Selector.MatchLabels)
-	return c.ClientSet.CoreV1().Pods(ns).List(c.defaultContext(), opts)
+	list, err := c.ClientSet.CoreV1().Pods(ns).List(ctx, opts)
+	return list, c.wrapError(err, "Pod", ns, "")
 }
 
-func (c *clientSetClient) PodList(ns string) (*apiv1.PodList, error) {
-	opts := metav1.ListOptions{}
-	return c.ClientSet.CoreV1().Pods(ns).List(c.defaultContext(), opts)
+func (c *clientSetClient) PodList(ns string, opts ...ListOpts) (*apiv1.PodList, error) {
+	return c.PodListContext(c.defaultContext(), ns, opts...)
+}
+
+func (c *clientSetClient) PodListContext(ctx context.Context, ns string, opts ...ListOpts) (*apiv1.PodList, error) {
+	o := listOpts(opts)
+	if o.cacheable() && c.Cache.covers(ns) {
+		list, err := c.Cache.podList(ns, o)
+		return list, c.wrapError(err, "Pod", ns, "")
+	}
+	list, err := c.ClientSet.CoreV1().Pods(ns).List(ctx, o.ListOptions())
+	return list, c.wrapError(err, "Pod", ns, "")
+}
+
+// Pods 查询匹配 labelSelector 的 pod, opts 中的 LabelSelector 会与 labelSelector 合并
+func (c *clientSetClient) Pods(ns string, labelSelector map[string]string, opts ...ListOpts) (*apiv1.PodList, error) {
+	return c.PodsContext(c.defaultContext(), ns, labelSelector, opts...)
 }
 
-func (c *clientSetClient) Pods(ns string, labelSelector map[string]string) (*apiv1.PodList, error) {
-	opts := metav1.ListOptions{
-		LabelSelector: labels.FormatLabels(labelSelector),
+func (c *clientSetClient) PodsContext(ctx context.Context, ns string, labelSelector map[string]string, opts ...ListOpts) (*apiv1.PodList, error) {
+	o := listOpts(opts)
+	if o.LabelSelector == "" {
+		o.LabelSelector = labels.FormatLabels(labelSelector)
+	} else if len(labelSelector) > 0 {
+		o.LabelSelector = labels.FormatLabels(labelSelector) + "," + o.LabelSelector
 	}
-	return c.ClientSet.CoreV1().Pods(ns).List(c.defaultContext(), opts)
+	return c.PodListContext(ctx, ns, o)
 }
 
 // PodDelete 删除单个Pod
 func (c *clientSetClient) PodDelete(ns, podName string) error {
+	return c.PodDeleteContext(c.defaultContext(), ns, podName)
+}
+
+func (c *clientSetClient) PodDeleteContext(ctx context.Context, ns, podName string) error {
 	opts := metav1.DeleteOptions{}
-	return c.ClientSet.CoreV1().Pods(ns).Delete(c.defaultContext(), podName, opts)
+	return c.wrapError(c.ClientSet.CoreV1().Pods(ns).Delete(ctx, podName, opts), "Pod", ns, podName)
 }
 
 // PodGet 查询Pod信息
 func (c *clientSetClient) PodGet(namespace, podName string) (*apiv1.Pod, error) {
+	return c.PodGetContext(c.defaultContext(), namespace, podName)
+}
+
+func (c *clientSetClient) PodGetContext(ctx context.Context, namespace, podName string) (*apiv1.Pod, error) {
+	if c.Cache.covers(namespace) {
+		pod, err := c.Cache.podGet(namespace, podName)
+		return pod, c.wrapError(err, "Pod", namespace, podName)
+	}
 	opts := metav1.GetOptions{}
-	return c.ClientSet.CoreV1().Pods(namespace).Get(c.defaultContext(), podName, opts)
+	pod, err := c.ClientSet.CoreV1().Pods(namespace).Get(ctx, podName, opts)
+	return pod, c.wrapError(err, "Pod", namespace, podName)
 }
 
 // PodDetail 查询Pod 详细信息
 func (c *clientSetClient) PodDetail(namespace, podName string) (p PodInfo, err error) {
-	pod, err := c.PodGet(namespace, podName)
+	return c.PodDetailContext(c.defaultContext(), namespace, podName)
+}
+
+func (c *clientSetClient) PodDetailContext(ctx context.Context, namespace, podName string) (p PodInfo, err error) {
+	pod, err := c.PodGetContext(ctx, namespace, podName)
 	if err != nil {
 		return
 	}
-	p = PodInfo{
-		PodName: pod.Name,
-		PodIP:   pod.Status.PodIP,
-		HostIP:  pod.Status.HostIP,
-		Tag:     strings.Split(pod.Spec.Containers[0].Image, ":")[1],
-	}
-
-	if pod.ObjectMeta.DeletionTimestamp != nil {
-		p.Status = Terminating
-	} else {
-		p.Status = PodStatus(pod.Status.Phase)
-		if pod.Status.Phase == apiv1.PodRunning {
-			p.StartTime = pod.Status.StartTime.Format(common.SecLocalTimeFormat)
-		}
-	}
-
-	containerCount := len(pod.Status.ContainerStatuses)
-	var containerReadyCount int
-	for _, containerStatuses := range pod.Status.ContainerStatuses {
-		if containerStatuses.Ready == true {
-			containerReadyCount++
-		}
-		// container
-		c := Container{
-			ContainerID:  containerStatuses.ContainerID,
-			Name:         containerStatuses.Name,
-			Ready:        containerStatuses.Ready,
-			RestartCount: containerStatuses.RestartCount,
-		}
-		if containerStatuses.Ready {
-			c.StartTime = containerStatuses.State.Running.StartedAt.Format(common.SecLocalTimeFormat)
-		}
-		p.Containers = append(p.Containers, c)
-	}
-	// sort
-	optContainerSlice := p.Containers
-	sort.Slice(optContainerSlice, func(i, j int) bool {
-		return optContainerSlice[i].StartTime < optContainerSlice[j].StartTime
-	})
-	p.Containers = optContainerSlice
-
-	p.Ready = fmt.Sprintf("%d/%d", containerReadyCount, containerCount)
-
-	for _, cs := range pod.Status.ContainerStatuses {
-		p.RestartCount = cs.RestartCount
-		switch {
-		case cs.State.Waiting != nil:
-			p.Status = PodStatus(cs.State.Waiting.Reason)
-		}
-	}
-
-	var deltaVal int64
-	if pod.Status.StartTime == nil {
-		deltaVal = time.Now().Unix()
-	} else {
-		deltaVal = pod.Status.StartTime.Unix()
-	}
-	p.Age = common.RuntimeAge(time.Now().Unix() - deltaVal)
-	return
+	return podInfo(pod, c.podMetrics(ctx, namespace, podName)), nil
 }
 
 func (c *clientSetClient) PodYaml(ns string, podName string) (*apiv1.Pod, error) {
+	return c.PodYamlContext(c.defaultContext(), ns, podName)
+}
+
+func (c *clientSetClient) PodYamlContext(ctx context.Context, ns string, podName string) (*apiv1.Pod, error) {
 	opts := metav1.GetOptions{}
-	return c.ClientSet.CoreV1().Pods(ns).Get(c.defaultContext(), podName, opts)
+	pod, err := c.ClientSet.CoreV1().Pods(ns).Get(ctx, podName, opts)
+	return pod, c.wrapError(err, "Pod", ns, podName)
 }
 
-// PodExec inPod exec command
+// PodExec 通过 sh -c 执行 command 并返回去掉首尾空白的 stdout, 退出码非 0 时返回 ExecFailed 错误;
+// 需要 stdin、stderr 或退出码时使用 PodExecWithRequest
 func (c *clientSetClient) PodExec(namespace, podName, container, command string) (output string, err error) {
-	url := c.ClientSet.CoreV1().RESTClient().Post().
-		Resource("pods").
-		Name(podName).
-		Namespace(namespace).
-		SubResource("exec").
-		VersionedParams(&apiv1.PodExecOptions{
-			Container: container,
-			Command:   []string{"sh", "-c", command},
-			Stdin:     false,
-			Stdout:    true,
-			Stderr:    true,
-			TTY:       false,
-		}, scheme.ParameterCodec).URL()
-
-	var stdout, stderr bytes.Buffer
-	exec, err := remotecommand.NewSPDYExecutor(c.KubeConfig, "POST", url)
+	result, err := c.PodExecWithRequest(namespace, podName, ExecRequest{
+		Container: container,
+		Command:   []string{"sh", "-c", command},
+	})
 	if err != nil {
-		return
+		return "", err
 	}
-	if err = exec.Stream(remotecommand.StreamOptions{
-		Stdin:  nil,
-		Stdout: &stdout,
-		Stderr: &stderr,
-	}); err != nil {
-		return
-	}
-	if len(strings.TrimSpace(stderr.String())) == 0 {
-		return strings.TrimSpace(stdout.String()), nil
+	if result.ExitCode != 0 {
+		msg := strings.TrimSpace(result.Stderr)
+		if msg == "" {
+			msg = fmt.Sprintf("command terminated with exit code %d", result.ExitCode)
+		}
+		return "", c.execError(errors.New(msg), namespace, podName)
 	}
-	return "", errors.New(strings.TrimSpace(stderr.String()))
-
+	return strings.TrimSpace(result.Stdout), nil
 }
 
 // PodTTY inPod exec command
@@ -322,7 +358,7 @@ func (c *clientSetClient) PodTTY(namespace, podName, container, shellType string
 
 	exec, err := remotecommand.NewSPDYExecutor(c.KubeConfig, "POST", url)
 	if err != nil {
-		return
+		return c.wrapError(err, "Pod", namespace, podName)
 	}
 	term := NewWebTerminal(conn, cols, rows)
 	if err = exec.Stream(remotecommand.StreamOptions{
@@ -331,186 +367,335 @@ func (c *clientSetClient) PodTTY(namespace, podName, container, shellType string
 		Stderr: term,
 		Tty:    true,
 	}); err != nil {
-		return
+		return c.execError(err, namespace, podName)
 	}
 	return
 }
 
-// PodLogs 查看Pod日志
+// PodLogs 查看Pod日志, 返回最后 50 行, 需要更多参数时使用 PodLogsWithOpts
 func (c *clientSetClient) PodLogs(namespace string, podName, containerName string, follow bool) (io.ReadCloser, error) {
-	tailLines := int64(50)
-	opts := &apiv1.PodLogOptions{
+	return c.PodLogsContext(c.defaultContext(), namespace, podName, containerName, follow)
+}
+
+// PodLogsContext 查看Pod日志, ctx 取消时关闭日志流
+func (c *clientSetClient) PodLogsContext(ctx context.Context, namespace string, podName, containerName string, follow bool) (io.ReadCloser, error) {
+	return c.PodLogsWithOptsContext(ctx, namespace, podName, PodLogOpts{
+		Container: containerName,
 		Follow:    follow, // 对应kubectl logs -f参数
-		TailLines: &tailLines,
-	}
-	if containerName != "" {
-		opts.Container = containerName
-	}
-	return c.ClientSet.CoreV1().Pods(namespace).GetLogs(podName, opts).Stream(c.defaultContext())
+		TailLines: 50,
+	})
 }
 
-func (c *clientSetClient) NamespaceList() (*apiv1.NamespaceList, error) {
-	return c.NamespaceListWithOption(c.defaultContext(), metav1.ListOptions{})
+func (c *clientSetClient) NamespaceList(opts ...ListOpts) (*apiv1.NamespaceList, error) {
+	return c.NamespaceListContext(c.defaultContext(), opts...)
+}
+
+func (c *clientSetClient) NamespaceListContext(ctx context.Context, opts ...ListOpts) (*apiv1.NamespaceList, error) {
+	return c.NamespaceListWithOption(ctx, listOpts(opts).ListOptions())
 }
 
 func (c *clientSetClient) NamespaceWithContentList(ctx context.Context) (*apiv1.NamespaceList, error) {
-	return c.NamespaceListWithOption(ctx, metav1.ListOptions{})
+	return c.NamespaceListContext(ctx)
 }
 
 func (c *clientSetClient) NamespaceListWithOption(ctx context.Context, opts metav1.ListOptions) (*apiv1.NamespaceList, error) {
-	return c.ClientSet.CoreV1().Namespaces().List(ctx, opts)
+	list, err := c.ClientSet.CoreV1().Namespaces().List(ctx, opts)
+	return list, c.wrapError(err, "Namespace", "", "")
 }
 
 func (c *clientSetClient) NamespaceGet(name string) (*apiv1.Namespace, error) {
-	return c.ClientSet.CoreV1().Namespaces().Get(c.defaultContext(), name, metav1.GetOptions{})
+	return c.NamespaceGetContext(c.defaultContext(), name)
+}
+
+func (c *clientSetClient) NamespaceGetContext(ctx context.Context, name string) (*apiv1.Namespace, error) {
+	ns, err := c.ClientSet.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	return ns, c.wrapError(err, "Namespace", "", name)
 }
 
 func (c *clientSetClient) ServiceCreate(namespace string, service *apiv1.Service) (*apiv1.Service, error) {
+	return c.ServiceCreateContext(c.defaultContext(), namespace, service)
+}
+
+func (c *clientSetClient) ServiceCreateContext(ctx context.Context, namespace string, service *apiv1.Service) (*apiv1.Service, error) {
 	opts := metav1.CreateOptions{}
-	return c.ClientSet.CoreV1().Services(namespace).Create(c.defaultContext(), service, opts)
+	svc, err := c.ClientSet.CoreV1().Services(namespace).Create(ctx, service, opts)
+	return svc, c.wrapError(err, "Service", namespace, service.Name)
 }
 
 func (c *clientSetClient) ServiceGet(namespace, name string) (*apiv1.Service, error) {
+	return c.ServiceGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) ServiceGetContext(ctx context.Context, namespace, name string) (*apiv1.Service, error) {
+	if c.Cache.covers(namespace) {
+		svc, err := c.Cache.serviceGet(namespace, name)
+		return svc, c.wrapError(err, "Service", namespace, name)
+	}
 	opts := metav1.GetOptions{}
-	return c.ClientSet.CoreV1().Services(namespace).Get(c.defaultContext(), name, opts)
+	svc, err := c.ClientSet.CoreV1().Services(namespace).Get(ctx, name, opts)
+	return svc, c.wrapError(err, "Service", namespace, name)
 }
 
 func (c *clientSetClient) ServiceCreateYaml(namespace, serviceYaml string) (*apiv1.Service, error) {
+	return c.ServiceCreateYamlContext(c.defaultContext(), namespace, serviceYaml)
+}
+
+func (c *clientSetClient) ServiceCreateYamlContext(ctx context.Context, namespace, serviceYaml string) (*apiv1.Service, error) {
 	var svc apiv1.Service
 	svcBytes, err := yaml.YAMLToJSON([]byte(serviceYaml))
 	if err != nil {
-		return nil, err
+		return nil, NewError(ReasonParseFailed, "Service", namespace, "", err)
 	}
 	err = json.Unmarshal(svcBytes, &svc)
 	if err != nil {
-		return nil, err
+		return nil, NewError(ReasonParseFailed, "Service", namespace, "", err)
 	}
-	return c.ServiceCreate(namespace, &svc)
+	return c.ServiceCreateContext(ctx, namespace, &svc)
 }
 
 func (c *clientSetClient) ServiceUpdate(namespace string, service *apiv1.Service) (*apiv1.Service, error) {
+	return c.ServiceUpdateContext(c.defaultContext(), namespace, service)
+}
+
+func (c *clientSetClient) ServiceUpdateContext(ctx context.Context, namespace string, service *apiv1.Service) (*apiv1.Service, error) {
 	opts := metav1.UpdateOptions{}
-	return c.ClientSet.CoreV1().Services(namespace).Update(c.defaultContext(), service, opts)
+	svc, err := c.ClientSet.CoreV1().Services(namespace).Update(ctx, service, opts)
+	return svc, c.wrapError(err, "Service", namespace, service.Name)
 }
 
 func (c *clientSetClient) ServiceUpdateYaml(namespace, serviceYaml string) (*apiv1.Service, error) {
+	return c.ServiceUpdateYamlContext(c.defaultContext(), namespace, serviceYaml)
+}
+
+func (c *clientSetClient) ServiceUpdateYamlContext(ctx context.Context, namespace, serviceYaml string) (*apiv1.Service, error) {
 	var svc apiv1.Service
 	svcBytes, err := yaml.YAMLToJSON([]byte(serviceYaml))
 	if err != nil {
-		return nil, err
+		return nil, NewError(ReasonParseFailed, "Service", namespace, "", err)
 	}
 	err = json.Unmarshal(svcBytes, &svc)
 	if err != nil {
-		return nil, err
+		return nil, NewError(ReasonParseFailed, "Service", namespace, "", err)
 	}
-	opts := metav1.UpdateOptions{}
-	return c.ClientSet.CoreV1().Services(namespace).Update(c.defaultContext(), &svc, opts)
+	return c.ServiceUpdateContext(ctx, namespace, &svc)
 }
 
 func (c *clientSetClient) ServiceDelete(namespace, name string) error {
+	return c.ServiceDeleteContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) ServiceDeleteContext(ctx context.Context, namespace, name string) error {
 	deletePolicy := metav1.DeletePropagationForeground
 	opts := metav1.DeleteOptions{
 		PropagationPolicy: &deletePolicy,
 	}
-	return c.ClientSet.CoreV1().Services(namespace).Delete(c.defaultContext(), name, opts)
+	return c.wrapError(c.ClientSet.CoreV1().Services(namespace).Delete(ctx, name, opts), "Service", namespace, name)
 }
 
 func (c *clientSetClient) IngressCreate(namespace string, ingress *networkv1.Ingress) (*networkv1.Ingress, error) {
+	return c.IngressCreateContext(c.defaultContext(), namespace, ingress)
+}
+
+func (c *clientSetClient) IngressCreateContext(ctx context.Context, namespace string, ingress *networkv1.Ingress) (*networkv1.Ingress, error) {
 	opts := metav1.CreateOptions{}
-	return c.ClientSet.NetworkingV1().Ingresses(namespace).Create(c.defaultContext(), ingress, opts)
+	ing, err := c.ClientSet.NetworkingV1().Ingresses(namespace).Create(ctx, ingress, opts)
+	return ing, c.wrapError(err, "Ingress", namespace, ingress.Name)
 }
 
 func (c *clientSetClient) IngressGetByBeta1(namespace, name string) (*networkbeta1.Ingress, error) {
+	return c.IngressGetByBeta1Context(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) IngressGetByBeta1Context(ctx context.Context, namespace, name string) (*networkbeta1.Ingress, error) {
 	opts := metav1.GetOptions{}
-	return c.ClientSet.NetworkingV1beta1().Ingresses(namespace).Get(c.defaultContext(), name, opts)
+	ing, err := c.ClientSet.NetworkingV1beta1().Ingresses(namespace).Get(ctx, name, opts)
+	return ing, c.wrapError(err, "Ingress", namespace, name)
 }
 
 func (c *clientSetClient) IngressGet(namespace, name string) (*networkv1.Ingress, error) {
+	return c.IngressGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) IngressGetContext(ctx context.Context, namespace, name string) (*networkv1.Ingress, error) {
 	opts := metav1.GetOptions{}
-	return c.ClientSet.NetworkingV1().Ingresses(namespace).Get(c.defaultContext(), name, opts)
+	ing, err := c.ClientSet.NetworkingV1().Ingresses(namespace).Get(ctx, name, opts)
+	return ing, c.wrapError(err, "Ingress", namespace, name)
 }
 
-func (c *clientSetClient) IngressList(namespace string) (*networkv1.IngressList, error) {
-	opts := metav1.ListOptions{}
-	return c.ClientSet.NetworkingV1().Ingresses(namespace).List(c.defaultContext(), opts)
+func (c *clientSetClient) IngressList(namespace string, opts ...ListOpts) (*networkv1.IngressList, error) {
+	return c.IngressListContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) IngressListContext(ctx context.Context, namespace string, opts ...ListOpts) (*networkv1.IngressList, error) {
+	o := listOpts(opts)
+	if o.cacheable() && c.Cache.covers(namespace) {
+		list, err := c.Cache.ingressList(namespace, o)
+		return list, c.wrapError(err, "Ingress", namespace, "")
+	}
+	list, err := c.ClientSet.NetworkingV1().Ingresses(namespace).List(ctx, o.ListOptions())
+	return list, c.wrapError(err, "Ingress", namespace, "")
 }
 
 func (c *clientSetClient) IngressCreateYaml(namespace, ingressYaml string) (*networkv1.Ingress, error) {
+	return c.IngressCreateYamlContext(c.defaultContext(), namespace, ingressYaml)
+}
+
+func (c *clientSetClient) IngressCreateYamlContext(ctx context.Context, namespace, ingressYaml string) (*networkv1.Ingress, error) {
 	var networkIngress networkv1.Ingress
 	svcBytes, err := yaml.YAMLToJSON([]byte(ingressYaml))
 	if err != nil {
-		return nil, err
+		return nil, NewError(ReasonParseFailed, "Ingress", namespace, "", err)
 	}
 	err = json.Unmarshal(svcBytes, &networkIngress)
 	if err != nil {
-		return nil, err
+		return nil, NewError(ReasonParseFailed, "Ingress", namespace, "", err)
 	}
-	return c.IngressCreate(namespace, &networkIngress)
+	return c.IngressCreateContext(ctx, namespace, &networkIngress)
 }
 
 func (c *clientSetClient) IngressUpdate(namespace string, ingress *networkv1.Ingress) (*networkv1.Ingress, error) {
+	return c.IngressUpdateContext(c.defaultContext(), namespace, ingress)
+}
+
+func (c *clientSetClient) IngressUpdateContext(ctx context.Context, namespace string, ingress *networkv1.Ingress) (*networkv1.Ingress, error) {
 	opts := metav1.UpdateOptions{}
-	return c.ClientSet.NetworkingV1().Ingresses(namespace).Update(c.defaultContext(), ingress, opts)
+	ing, err := c.ClientSet.NetworkingV1().Ingresses(namespace).Update(ctx, ingress, opts)
+	return ing, c.wrapError(err, "Ingress", namespace, ingress.Name)
 }
 
 func (c *clientSetClient) IngressDelete(namespace, name string) error {
+	return c.IngressDeleteContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) IngressDeleteContext(ctx context.Context, namespace, name string) error {
 	deletePolicy := metav1.DeletePropagationForeground
 	opts := metav1.DeleteOptions{
 		PropagationPolicy: &deletePolicy,
 	}
-	return c.ClientSet.ExtensionsV1beta1().Ingresses(namespace).Delete(c.defaultContext(), name, opts)
+	return c.wrapError(c.ClientSet.ExtensionsV1beta1().Ingresses(namespace).Delete(ctx, name, opts), "Ingress", namespace, name)
 }
 
 func (c *clientSetClient) SecretCreate(namespace string, secret *apiv1.Secret) (*apiv1.Secret, error) {
+	return c.SecretCreateContext(c.defaultContext(), namespace, secret)
+}
+
+func (c *clientSetClient) SecretCreateContext(ctx context.Context, namespace string, secret *apiv1.Secret) (*apiv1.Secret, error) {
 	opts := metav1.CreateOptions{}
-	return c.ClientSet.CoreV1().Secrets(namespace).Create(c.defaultContext(), secret, opts)
+	sec, err := c.ClientSet.CoreV1().Secrets(namespace).Create(ctx, secret, opts)
+	return sec, c.wrapError(err, "Secret", namespace, secret.Name)
+}
+
+func (c *clientSetClient) SecretGet(namespace, name string) (*apiv1.Secret, error) {
+	return c.SecretGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) SecretGetContext(ctx context.Context, namespace, name string) (*apiv1.Secret, error) {
+	opts := metav1.GetOptions{}
+	sec, err := c.ClientSet.CoreV1().Secrets(namespace).Get(ctx, name, opts)
+	return sec, c.wrapError(err, "Secret", namespace, name)
+}
+
+func (c *clientSetClient) SecretUpdate(namespace string, secret *apiv1.Secret) (*apiv1.Secret, error) {
+	return c.SecretUpdateContext(c.defaultContext(), namespace, secret)
+}
+
+func (c *clientSetClient) SecretUpdateContext(ctx context.Context, namespace string, secret *apiv1.Secret) (*apiv1.Secret, error) {
+	opts := metav1.UpdateOptions{}
+	sec, err := c.ClientSet.CoreV1().Secrets(namespace).Update(ctx, secret, opts)
+	return sec, c.wrapError(err, "Secret", namespace, secret.Name)
 }
 
 func (c *clientSetClient) SecretDelete(namespace, name string) error {
+	return c.SecretDeleteContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) SecretDeleteContext(ctx context.Context, namespace, name string) error {
 	opts := metav1.DeleteOptions{}
-	return c.ClientSet.CoreV1().Secrets(namespace).Delete(c.defaultContext(), name, opts)
+	return c.wrapError(c.ClientSet.CoreV1().Secrets(namespace).Delete(ctx, name, opts), "Secret", namespace, name)
 }
 
-func (c *clientSetClient) PvcList(namespace string) (*apiv1.PersistentVolumeClaimList, error) {
-	opts := metav1.ListOptions{}
-	return c.ClientSet.CoreV1().PersistentVolumeClaims(namespace).List(c.defaultContext(), opts)
+func (c *clientSetClient) PvcList(namespace string, opts ...ListOpts) (*apiv1.PersistentVolumeClaimList, error) {
+	return c.PvcListContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) PvcListContext(ctx context.Context, namespace string, opts ...ListOpts) (*apiv1.PersistentVolumeClaimList, error) {
+	list, err := c.ClientSet.CoreV1().PersistentVolumeClaims(namespace).List(ctx, listOpts(opts).ListOptions())
+	return list, c.wrapError(err, "PersistentVolumeClaim", namespace, "")
 }
 
 func (c *clientSetClient) ConfigmapGet(namespace, name string) (*apiv1.ConfigMap, error) {
+	return c.ConfigmapGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) ConfigmapGetContext(ctx context.Context, namespace, name string) (*apiv1.ConfigMap, error) {
 	opts := metav1.GetOptions{}
-	return c.ClientSet.CoreV1().ConfigMaps(namespace).Get(c.defaultContext(), name, opts)
+	cm, err := c.ClientSet.CoreV1().ConfigMaps(namespace).Get(ctx, name, opts)
+	return cm, c.wrapError(err, "ConfigMap", namespace, name)
 }
 
 func (c *clientSetClient) ConfigmapCreate(namespace string, cm *apiv1.ConfigMap) (*apiv1.ConfigMap, error) {
+	return c.ConfigmapCreateContext(c.defaultContext(), namespace, cm)
+}
+
+func (c *clientSetClient) ConfigmapCreateContext(ctx context.Context, namespace string, cm *apiv1.ConfigMap) (*apiv1.ConfigMap, error) {
 	opts := metav1.CreateOptions{}
-	return c.ClientSet.CoreV1().ConfigMaps(namespace).Create(c.defaultContext(), cm, opts)
+	configMap, err := c.ClientSet.CoreV1().ConfigMaps(namespace).Create(ctx, cm, opts)
+	return configMap, c.wrapError(err, "ConfigMap", namespace, cm.Name)
+}
+
+func (c *clientSetClient) ConfigmapUpdate(namespace string, cm *apiv1.ConfigMap) (*apiv1.ConfigMap, error) {
+	return c.ConfigmapUpdateContext(c.defaultContext(), namespace, cm)
+}
+
+func (c *clientSetClient) ConfigmapUpdateContext(ctx context.Context, namespace string, cm *apiv1.ConfigMap) (*apiv1.ConfigMap, error) {
+	opts := metav1.UpdateOptions{}
+	configMap, err := c.ClientSet.CoreV1().ConfigMaps(namespace).Update(ctx, cm, opts)
+	return configMap, c.wrapError(err, "ConfigMap", namespace, cm.Name)
 }
 
 func (c *clientSetClient) HpaGet(namespace, name string) (*autoscallingv1.HorizontalPodAutoscaler, error) {
+	return c.HpaGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) HpaGetContext(ctx context.Context, namespace, name string) (*autoscallingv1.HorizontalPodAutoscaler, error) {
 	opts := metav1.GetOptions{}
-	return c.ClientSet.AutoscalingV1().HorizontalPodAutoscalers(namespace).Get(c.defaultContext(), name, opts)
+	hpa, err := c.ClientSet.AutoscalingV1().HorizontalPodAutoscalers(namespace).Get(ctx, name, opts)
+	return hpa, c.wrapError(err, "HorizontalPodAutoscaler", namespace, name)
+
+}
 
+func (c *clientSetClient) EventsList(namespace string, opts ...ListOpts) (*v1beta1.EventList, error) {
+	return c.EventsListContext(c.defaultContext(), namespace, opts...)
 }
 
-func (c *clientSetClient) EventsList(namespace string) (*v1beta1.EventList, error) {
+func (c *clientSetClient) EventsListContext(ctx context.Context, namespace string, opts ...ListOpts) (*v1beta1.EventList, error) {
+	o := listOpts(opts)
+	if o.cacheable() && c.Cache.covers(namespace) {
+		list, err := c.Cache.eventList(namespace, o)
+		return list, c.wrapError(err, "Event", namespace, "")
+	}
 	// kubectl get events -A --sort-by=.metadata.creationTimestamp
 	// v1beta1.EventList
-	opts := metav1.ListOptions{}
-	return c.ClientSet.EventsV1beta1().Events(namespace).List(c.defaultContext(), opts)
+	list, err := c.ClientSet.EventsV1beta1().Events(namespace).List(ctx, o.ListOptions())
+	return list, c.wrapError(err, "Event", namespace, "")
 }
 
 // EventsDetail 格式化后的Event清单
 func (c *clientSetClient) EventsDetail() (eventList []Events, err error) {
+	return c.EventsDetailContext(c.defaultContext())
+}
+
+func (c *clientSetClient) EventsDetailContext(ctx context.Context) (eventList []Events, err error) {
 	// kubectl get events -A --sort-by=.metadata.creationTimestamp
 	// v1beta1.EventList
-	ns, err := c.NamespaceList()
+	ns, err := c.NamespaceListContext(ctx)
 	if err != nil {
 		return
 	}
 	for _, item := range ns.Items {
-		eventsList, err := c.ClientSet.EventsV1beta1().Events(item.Namespace).List(c.defaultContext(), metav1.ListOptions{})
+		eventsList, err := c.ClientSet.EventsV1beta1().Events(item.Namespace).List(ctx, metav1.ListOptions{})
 		if err != nil {
-			return eventList, err
+			return eventList, c.wrapError(err, "Event", item.Namespace, "")
 		}
 		for _, el := range eventsList.Items {
 			eventList = append(eventList, Events{
@@ -528,49 +713,74 @@ func (c *clientSetClient) EventsDetail() (eventList []Events, err error) {
 
 // NodeGet 获取指定Node
 func (c *clientSetClient) NodeGet(nodeName string) (*apiv1.Node, error) {
+	return c.NodeGetContext(c.defaultContext(), nodeName)
+}
+
+func (c *clientSetClient) NodeGetContext(ctx context.Context, nodeName string) (*apiv1.Node, error) {
 	opts := metav1.GetOptions{}
-	return c.ClientSet.CoreV1().Nodes().Get(c.defaultContext(), nodeName, opts)
+	node, err := c.ClientSet.CoreV1().Nodes().Get(ctx, nodeName, opts)
+	return node, c.wrapError(err, "Node", "", nodeName)
 }
 
-// NodeListFormat 获取所有Node
-func (c *clientSetClient) NodeListFormat() (nodes []Node, err error) {
-	nodeList, err := c.ClientSet.CoreV1().Nodes().List(c.defaultContext(), metav1.ListOptions{})
+// NodeListFormat 获取所有 node 格式化数据, 包含按 node 汇总的 pod requests
+func (c *clientSetClient) NodeListFormat(opts ...ListOpts) (nodes []Node, err error) {
+	return c.NodeListFormatContext(c.defaultContext(), opts...)
+}
+
+func (c *clientSetClient) NodeListFormatContext(ctx context.Context, opts ...ListOpts) (nodes []Node, err error) {
+	nodeList, err := c.nodeList(ctx, listOpts(opts))
 	if err != nil {
 		return
 	}
-
-	for _, item := range nodeList.Items {
-		createTime := item.CreationTimestamp.Format(common.SecLocalTimeFormat)
-		nodes = append(nodes, Node{
-			Name:             item.Name,
-			Age:              common.SubTime(createTime, common.Now()),
-			KernelVersion:    item.Status.NodeInfo.KernelVersion,
-			OsImage:          item.Status.NodeInfo.OSImage,
-			Version:          item.Status.NodeInfo.KubeletVersion,
-			Cpu:              item.Status.Capacity.Cpu().String(),
-			Mem:              item.Status.Allocatable.Memory().String(),
-			Status:           item.Status.Conditions[len(item.Status.Conditions)-1].Type,
-			ContainerRuntime: item.Status.NodeInfo.ContainerRuntimeVersion,
-			Labels:           item.Labels,
-		})
+	pods, err := c.nodePods(ctx, "")
+	if err != nil {
+		return
+	}
+	usage := c.nodeMetrics(ctx)
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		info := nodeInfo(node, pods[node.Name])
+		info.Usage = nodeUsage(node, usage[node.Name])
+		nodes = append(nodes, info)
 	}
 	return
 }
 
+func (c *clientSetClient) nodeList(ctx context.Context, o ListOpts) (nodeList *apiv1.NodeList, err error) {
+	if o.cacheable() && c.Cache.covers("") {
+		nodeList, err = c.Cache.nodeList(o)
+	} else {
+		nodeList, err = c.ClientSet.CoreV1().Nodes().List(ctx, o.ListOptions())
+	}
+	return nodeList, c.wrapError(err, "Node", "", "")
+}
+
 // DeploymentPause 暂停Deployment升级
 func (c *clientSetClient) DeploymentPause(namespace, name string) (*appsv1.Deployment, error) {
+	return c.DeploymentPauseContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DeploymentPauseContext(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
 	data := []byte(fmt.Sprintf(`{"spec":{"paused": true}}`))
-	return c.deploymentPatch(namespace, c.defaultContext(), name, types.MergePatchType, data, metav1.PatchOptions{})
+	return c.deploymentPatch(namespace, ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
 }
 
 // DeploymentResume 恢复Deployment升级
 func (c *clientSetClient) DeploymentResume(namespace, name string) (*appsv1.Deployment, error) {
+	return c.DeploymentResumeContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DeploymentResumeContext(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
 	data := []byte(fmt.Sprintf(`{"spec":{"paused": false}}`))
-	return c.deploymentPatch(namespace, c.defaultContext(), name, types.MergePatchType, data, metav1.PatchOptions{})
+	return c.deploymentPatch(namespace, ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
 }
 
 // DeploymentUpdateReplicas 修改 deployment 副本数
 func (c *clientSetClient) DeploymentUpdateReplicas(namespace, name string, replicas int) (*appsv1.Deployment, error) {
+	return c.DeploymentUpdateReplicasContext(c.defaultContext(), namespace, name, replicas)
+}
+
+func (c *clientSetClient) DeploymentUpdateReplicasContext(ctx context.Context, namespace, name string, replicas int) (*appsv1.Deployment, error) {
 	data := map[string]map[string]int{
 		"spec": {
 			"replicas": replicas,
@@ -580,10 +790,21 @@ func (c *clientSetClient) DeploymentUpdateReplicas(namespace, name string, repli
 	if err != nil {
 		return nil, err
 	}
-	return c.deploymentPatch(namespace, c.defaultContext(), name, types.MergePatchType, byteMarshal, metav1.PatchOptions{})
+	return c.deploymentPatch(namespace, ctx, name, types.MergePatchType, byteMarshal, metav1.PatchOptions{})
 }
 
 func (c *clientSetClient) deploymentPatch(namespace string, ctx context.Context, name string,
 	pt types.PatchType, data []byte, opts metav1.PatchOptions, subResources ...string) (*appsv1.Deployment, error) {
-	return c.ClientSet.AppsV1().Deployments(namespace).Patch(ctx, name, pt, data, opts, subResources...)
+	deploy, err := c.ClientSet.AppsV1().Deployments(namespace).Patch(ctx, name, pt, data, opts, subResources...)
+	return deploy, c.wrapError(err, "Deployment", namespace, name)
+}
+
+// execError exec 失败时, 非 apiserver 返回的错误统一归类为 ExecFailed
+func (c *clientSetClient) execError(err error, namespace, podName string) error {
+	if reason := ReasonOf(err); reason != ReasonUnknown {
+		return c.wrapError(err, "Pod", namespace, podName)
+	}
+	e := NewError(ReasonExecFailed, "Pod", namespace, podName, err)
+	e.Cluster = c.Cluster
+	return e
 }
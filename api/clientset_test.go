@@ -1,78 +1,140 @@
 package api
 
 import (
-	"fmt"
 	"testing"
 
-	"github.com/kr/pretty"
 	"github.com/stretchr/testify/assert"
-	"k8s.io/client-go/kubernetes"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/events/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
-var c = kubernetes.Clientset{}
-
 func TestDeploymentList(t *testing.T) {
 	tests := []struct {
-		name   string
-		input  map[string]string
-		expect *kubernetes.Clientset
+		name    string
+		objects []runtime.Object
+		input   map[string]string
+		expect  []DeploymentInfo
 	}{
 		{
 			name: "list deployment",
+			objects: []runtime.Object{
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "cn-online"},
+					Status:     appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 2, AvailableReplicas: 2, UnavailableReplicas: 1},
+				},
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "cn-dev"},
+				},
+			},
+			input: map[string]string{
+				"namespace": "cn-online",
+			},
+			expect: []DeploymentInfo{
+				{Name: "nginx", Replicas: 3, ReadyReplicas: 2, AvailableReplicas: 2, UnavailableReplicas: 1, Phase: "Healthy"},
+			},
+		},
+		{
+			name: "empty namespace",
 			input: map[string]string{
-				"kube_config": dmzClusterConfig,
-				"namespace":   "cn-online",
+				"namespace": "cn-online",
 			},
-			expect: &c,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			c, err := NewClientSet(test.input["kube_config"])
-			if err != nil {
-				t.Fatal(err)
-			}
+			c := NewFakeClientSet(test.objects...)
 			deployment, err := c.DeploymentListFormat(test.input["namespace"])
 			if err != nil {
 				t.Fatal(err)
 			}
-
-			//assert.EqualValues(t, test.expect, actual)
-			fmt.Printf("%# v\n", pretty.Formatter(deployment))
+			for i := range deployment {
+				deployment[i].Age = ""
+			}
+			assert.EqualValues(t, test.expect, deployment)
 		})
 	}
 }
 
 func TestNodeList(t *testing.T) {
 	tests := []struct {
-		name   string
-		input  map[string]string
-		expect *kubernetes.Clientset
+		name    string
+		objects []runtime.Object
+		expect  []string
 	}{
 		{
 			name: "list nodes",
-			input: map[string]string{
-				"kube_config": dmzClusterConfig,
+			objects: []runtime.Object{
+				&apiv1.Node{
+					ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+					Status: apiv1.NodeStatus{
+						Conditions: []apiv1.NodeCondition{{Type: apiv1.NodeReady, Status: apiv1.ConditionTrue}},
+						NodeInfo:   apiv1.NodeSystemInfo{KubeletVersion: "v1.23.5"},
+					},
+				},
 			},
-			expect: &c,
+			expect: []string{"node-1"},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			c, err := NewClientSet(test.input["kube_config"])
-			if err != nil {
-				t.Fatal(err)
-			}
-			actual := c
+			c := NewFakeClientSet(test.objects...)
 			nodes, err := c.NodeListFormat()
 			if err != nil {
 				t.Fatal(err)
 			}
 
-			assert.EqualValues(t, test.expect, actual)
-			fmt.Printf("%# v\n", pretty.Formatter(nodes))
+			var names []string
+			for _, node := range nodes {
+				names = append(names, node.Name)
+				assert.EqualValues(t, "v1.23.5", node.Version)
+			}
+			assert.EqualValues(t, test.expect, names)
 		})
 	}
 }
+
+func TestPodEventsGet(t *testing.T) {
+	c := NewFakeClientSet(
+		&apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx-0", Namespace: "cn-online", Labels: map[string]string{"app": "nginx"}},
+			Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "nginx", Image: "nginx:1.21"}}},
+		},
+		&apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx-1", Namespace: "cn-online", Labels: map[string]string{"app": "nginx"}},
+			Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "nginx", Image: "nginx:1.21"}}},
+		},
+		&v1beta1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx-0.pulled", Namespace: "cn-online"},
+			Regarding:  apiv1.ObjectReference{Kind: "Pod", Name: "nginx-0", Namespace: "cn-online"},
+			Reason:     "Pulled",
+			Note:       "Successfully pulled image",
+		},
+	)
+	c.Metrics = &FakeMetricsSource{Pods: []PodMetrics{{ObjectMeta: metav1.ObjectMeta{Name: "nginx-0", Namespace: "cn-online"}}}}
+	// 只 list pod 和 event, 不逐个查询 pod
+	var gets int
+	c.ClientSet.(*fake.Clientset).PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gets++
+		return false, nil, nil
+	})
+
+	pods, err := c.PodEventsGet("cn-online", "nginx")
+	assert.NoError(t, err)
+	assert.Len(t, pods, 2)
+	assert.EqualValues(t, 0, gets)
+	for _, pod := range pods {
+		assert.Nil(t, pod.Containers[0].Usage)
+		if pod.PodName == "nginx-0" {
+			assert.EqualValues(t, []*PodEvent{{PodName: "nginx-0", Message: "Pulled", Note: "Successfully pulled image"}}, pod.Events)
+		} else {
+			assert.Empty(t, pod.Events)
+		}
+	}
+}
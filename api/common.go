@@ -9,12 +9,12 @@ type PodEvent struct {
 }
 
 type PodInfo struct {
-	Status       PodStatus   `json:"status"`
+	Status       PodStatus   `json:"status"` // 与 kubectl get pod 的 STATUS 列一致
 	Age          string      `json:"age"`
 	Ready        string      `json:"ready"`
 	PodIP        string      `json:"pod_ip"`
 	PodName      string      `json:"pod_name"`
-	RestartCount int32       `json:"restart_count"`
+	RestartCount int32       `json:"restart_count"` // 所有容器重启次数之和
 	HostIP       string      `json:"host_ip"`
 	Tag          string      `json:"tag"`
 	StartTime    string      `json:"start_time"`
@@ -24,11 +24,33 @@ type PodInfo struct {
 }
 
 type Container struct {
-	ContainerID  string `json:"containerID"`
-	Name         string `json:"name"`
-	Ready        bool   `json:"ready"`
-	RestartCount int32  `json:"restartCount"`
-	StartTime    string `json:"startTime"`
+	ContainerID  string   `json:"containerID"`
+	Name         string   `json:"name"`
+	Type         string   `json:"type"` // init/container/ephemeral
+	Image        string   `json:"image"`
+	ImageRef     ImageRef `json:"imageRef"`
+	State        string   `json:"state"`  // Running/Waiting/Terminated
+	Reason       string   `json:"reason"` // Waiting/Terminated 的原因, 如 CrashLoopBackOff, Completed
+	Ready        bool     `json:"ready"`
+	RestartCount int32    `json:"restartCount"`
+	StartTime    string   `json:"startTime"`
+	// LastTermination 上一次退出的信息, 用于排查重启原因
+	LastTermination *ContainerTermination `json:"lastTermination,omitempty"`
+	// Usage 容器实时用量, 未安装 metrics-server 时为空
+	Usage *ContainerUsage `json:"usage,omitempty"`
+}
+
+type ContainerTermination struct {
+	Reason     string `json:"reason"`
+	ExitCode   int32  `json:"exitCode"`
+	Signal     int32  `json:"signal"`
+	Message    string `json:"message"`
+	FinishedAt string `json:"finishedAt"`
+}
+
+type ContainerUsage struct {
+	Cpu string `json:"cpu"`
+	Mem string `json:"mem"`
 }
 
 type DeploymentInfo struct {
@@ -41,6 +63,68 @@ type DeploymentInfo struct {
 	Phase               string `json:"phase"`
 }
 
+type StatefulSetInfo struct {
+	Name            string       `json:"name"`
+	Age             string       `json:"age"`
+	DesiredReplicas int32        `json:"desired_replicas"` // 期望副本数
+	Replicas        int32        `json:"replicas"`         // 已创建副本数
+	ReadyReplicas   int32        `json:"ready_replicas"`
+	CurrentReplicas int32        `json:"current_replicas"` // 处于 CurrentRevision 的副本数
+	UpdatedReplicas int32        `json:"updated_replicas"` // 处于 UpdateRevision 的副本数
+	CurrentRevision string       `json:"current_revision"`
+	UpdateRevision  string       `json:"update_revision"`
+	UpdateStrategy  string       `json:"update_strategy"`
+	Partition       int32        `json:"partition"`
+	Phase           string       `json:"phase"`
+	Pods            []OrdinalPod `json:"pods"` // 按序号排序
+}
+
+type OrdinalPod struct {
+	Name     string `json:"name"`
+	Ordinal  int    `json:"ordinal"`
+	Ready    bool   `json:"ready"`
+	Phase    string `json:"phase"`
+	Revision string `json:"revision"`
+	NodeName string `json:"node_name"`
+}
+
+type DaemonSetInfo struct {
+	Name           string            `json:"name"`
+	Age            string            `json:"age"`
+	Desired        int32             `json:"desired"` // 应运行 pod 的 node 数
+	Current        int32             `json:"current"` // 已运行 pod 的 node 数
+	Ready          int32             `json:"ready"`
+	Updated        int32             `json:"updated"` // 运行最新版本 pod 的 node 数
+	Available      int32             `json:"available"`
+	Misscheduled   int32             `json:"misscheduled"` // 不应运行却运行了 pod 的 node 数
+	UpdateStrategy string            `json:"update_strategy"`
+	NodeSelector   map[string]string `json:"node_selector"`
+	Phase          string            `json:"phase"`
+}
+
+type DaemonSetNodePod struct {
+	NodeName string `json:"node_name"`
+	PodName  string `json:"pod_name"`
+	Phase    string `json:"phase"`
+	Ready    bool   `json:"ready"`
+	Revision string `json:"revision"`
+	Updated  bool   `json:"updated"` // 是否已是最新版本
+}
+
+type JobInfo struct {
+	Name           string `json:"name"`
+	Age            string `json:"age"`
+	Status         string `json:"status"` // Running/Succeeded/Failed/Suspended
+	Completions    int32  `json:"completions"`
+	Active         int32  `json:"active"`
+	Succeeded      int32  `json:"succeeded"`
+	Failed         int32  `json:"failed"`
+	StartTime      string `json:"start_time"`
+	CompletionTime string `json:"completion_time"`
+	Duration       string `json:"duration"`
+	Message        string `json:"message"`
+}
+
 type Namespace struct {
 	Name  string
 	Phase string
@@ -49,10 +133,10 @@ type Namespace struct {
 
 type Node struct {
 	Name             string            `json:"name"`
-	Status           interface{}       `json:"status"`
+	Status           interface{}       `json:"status"` // 值为 string: Ready/NotReady/Unknown, 不可调度时追加 ,SchedulingDisabled
 	Roles            string            `json:"roles"`
-	Cpu              string            `json:"cpu"`
-	Mem              string            `json:"mem"`
+	Cpu              string            `json:"cpu"` // allocatable
+	Mem              string            `json:"mem"` // allocatable
 	Age              string            `json:"age"`
 	Version          string            `json:"version"`
 	InternalIp       string            `json:"internal_ip"`
@@ -61,6 +145,28 @@ type Node struct {
 	KernelVersion    string            `json:"kernel_version"`
 	ContainerRuntime string            `json:"container_runtime"`
 	Labels           map[string]string `json:"labels"`
+	Taints           []string          `json:"taints"`
+	Pressure         []string          `json:"pressure"` // 状态为 True 的 MemoryPressure/DiskPressure/PIDPressure/NetworkUnavailable
+	CpuAllocation    NodeAllocation    `json:"cpu_allocation"`
+	MemAllocation    NodeAllocation    `json:"mem_allocation"`
+	PodAllocation    NodeAllocation    `json:"pod_allocation"`
+	// Usage node 实时用量, 未安装 metrics-server 时为空
+	Usage *NodeUsage `json:"usage,omitempty"`
+}
+
+// NodeUsage node 实时用量, 百分比相对于 allocatable
+type NodeUsage struct {
+	Cpu        string `json:"cpu"`
+	CpuPercent int64  `json:"cpu_percent"`
+	Mem        string `json:"mem"`
+	MemPercent int64  `json:"mem_percent"`
+}
+
+// NodeAllocation node 上已调度 pod 的 requests 与 allocatable 对比
+type NodeAllocation struct {
+	Requested   string `json:"requested"`
+	Allocatable string `json:"allocatable"`
+	Percent     int64  `json:"percent"`
 }
 
 type Events struct {
@@ -71,3 +177,11 @@ type Events struct {
 	Object    string `json:"object"`
 	Message   string `json:"message"`
 }
+
+type KubeContext struct {
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace"`
+	Current   bool   `json:"current"`
+}
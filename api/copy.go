@@ -0,0 +1,268 @@
+package api
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// CopyOpts 容器文件复制参数
+type CopyOpts struct {
+	Container string // 为空时使用默认容器
+	// Progress 每写入一块数据调用一次, 可以为 nil
+	Progress func(CopyProgress)
+}
+
+// CopyProgress 复制进度
+type CopyProgress struct {
+	File    string `json:"file"`    // 当前文件, 为 tar 中的相对路径
+	Size    int64  `json:"size"`    // 当前文件大小
+	Written int64  `json:"written"` // 当前文件已复制的字节数
+	Total   int64  `json:"total"`   // 所有文件已复制的字节数
+}
+
+// CopyFromPod 将容器中的 srcPath 复制到本地 destPath, 与 kubectl cp 一致, srcPath 为目录时 destPath 为目录,
+// 为文件时 destPath 为文件; 保留文件权限, 链接和设备文件会被跳过. 需要容器中有 tar 命令
+func (c *clientSetClient) CopyFromPod(namespace, podName, srcPath, destPath string, opts CopyOpts) error {
+	return c.CopyFromPodContext(c.defaultContext(), namespace, podName, srcPath, destPath, opts)
+}
+
+func (c *clientSetClient) CopyFromPodContext(ctx context.Context, namespace, podName, srcPath, destPath string, opts CopyOpts) error {
+	dir, base, err := splitCopyPath(srcPath)
+	if err != nil || destPath == "" {
+		if err == nil {
+			err = fmt.Errorf("destination path must not be empty")
+		}
+		e := NewError(ReasonInvalid, "Pod", namespace, podName, err)
+		e.Cluster = c.Cluster
+		return e
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pr, pw := io.Pipe()
+	type execResult struct {
+		result *ExecResult
+		err    error
+	}
+	done := make(chan execResult, 1)
+	go func() {
+		result, err := c.PodExecWithRequestContext(ctx, namespace, podName, ExecRequest{
+			Container: opts.Container,
+			Command:   []string{"tar", "cf", "-", "-C", dir, "--", base},
+			Stdout:    pw,
+		})
+		pw.Close()
+		done <- execResult{result, err}
+	}()
+
+	err = extractTar(pr, base, destPath, opts.Progress)
+	if err == nil {
+		// tar 结尾可能还有填充的空块
+		_, err = io.Copy(io.Discard, pr)
+	}
+	if err != nil {
+		// 停止读取后容器中的 tar 会阻塞, 取消 exec 并关闭管道
+		cancel()
+		pr.CloseWithError(err)
+	}
+	r := <-done
+	if r.err == nil && r.result.ExitCode != 0 {
+		return c.copyError(r.result, namespace, podName)
+	}
+	if err != nil {
+		return c.wrapError(err, "Pod", namespace, podName)
+	}
+	return r.err
+}
+
+// CopyToPod 将本地的 srcPath 复制到容器中的 destPath, srcPath 为目录时递归复制; 保留文件权限, 链接和设备文件会被跳过.
+// 需要容器中有 tar 命令, 且 destPath 的父目录已存在
+func (c *clientSetClient) CopyToPod(namespace, podName, srcPath, destPath string, opts CopyOpts) error {
+	return c.CopyToPodContext(c.defaultContext(), namespace, podName, srcPath, destPath, opts)
+}
+
+func (c *clientSetClient) CopyToPodContext(ctx context.Context, namespace, podName, srcPath, destPath string, opts CopyOpts) error {
+	dir, base, err := splitCopyPath(destPath)
+	if err == nil {
+		_, err = os.Stat(srcPath)
+	}
+	if err != nil {
+		e := NewError(ReasonInvalid, "Pod", namespace, podName, err)
+		e.Cluster = c.Cluster
+		return e
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTar(pw, srcPath, base, opts.Progress))
+	}()
+	result, err := c.PodExecWithRequestContext(ctx, namespace, podName, ExecRequest{
+		Container: opts.Container,
+		Command:   []string{"tar", "xmf", "-", "-C", dir},
+		Stdin:     pr,
+	})
+	// 容器中的 tar 提前退出时让 writeTar 结束
+	pr.Close()
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return c.copyError(result, namespace, podName)
+	}
+	return nil
+}
+
+func (c *clientSetClient) copyError(result *ExecResult, namespace, podName string) error {
+	msg := strings.TrimSpace(result.Stderr)
+	if msg == "" {
+		msg = fmt.Sprintf("tar terminated with exit code %d", result.ExitCode)
+	}
+	return c.execError(errors.New(msg), namespace, podName)
+}
+
+// splitCopyPath 拆分容器中的路径, 不允许复制根目录; 以 - 开头的相对路径加上 ./ 前缀, 避免被 tar 当作选项
+func splitCopyPath(p string) (dir, base string, err error) {
+	p = path.Clean(p)
+	dir, base = path.Dir(p), path.Base(p)
+	if base == "/" || base == "." || base == ".." {
+		return "", "", fmt.Errorf("invalid path %q", p)
+	}
+	if strings.HasPrefix(dir, "-") {
+		dir = "./" + dir
+	}
+	return dir, base, nil
+}
+
+// extractTar 将 tar 中 base 下的文件解压到 destPath, base 对应 destPath 本身;
+// base 以外的路径 (绝对路径或包含 ..) 返回错误, 链接被跳过以免后续文件通过链接写到 destPath 之外
+func extractTar(r io.Reader, base, destPath string, progress func(CopyProgress)) error {
+	tr := tar.NewReader(r)
+	var (
+		total int64
+		dirs  []*tar.Header
+	)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(header.Name)
+		if name != base && !strings.HasPrefix(name, base+"/") {
+			return fmt.Errorf("tar entry %q is outside of %q", header.Name, base)
+		}
+		target := filepath.Join(destPath, filepath.FromSlash(strings.TrimPrefix(name, base)))
+		if rel, err := filepath.Rel(destPath, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("tar entry %q is outside of %q", header.Name, destPath)
+		}
+
+		mode := header.FileInfo().Mode().Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			dirs = append(dirs, header)
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+			if err != nil {
+				return err
+			}
+			w := &progressWriter{w: f, progress: progress, total: &total,
+				p: CopyProgress{File: name, Size: header.Size}}
+			_, err = io.Copy(w, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err == nil {
+				// OpenFile 的权限受 umask 影响
+				err = os.Chmod(target, mode)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// 最后设置目录权限, 避免只读目录导致其中的文件无法写入
+	for _, header := range dirs {
+		target := filepath.Join(destPath, filepath.FromSlash(strings.TrimPrefix(path.Clean(header.Name), base)))
+		if err := os.Chmod(target, header.FileInfo().Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTar 将本地 srcPath 打包写入 w, tar 中的路径以 base 开头
+func writeTar(w io.Writer, srcPath, base string, progress func(CopyProgress)) error {
+	tw := tar.NewWriter(w)
+	var total int64
+	err := filepath.Walk(srcPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(srcPath, file)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(base, filepath.ToSlash(rel))
+		// 不携带本地用户信息, 文件属于容器中执行 tar 的用户
+		header.Uid, header.Gid, header.Uname, header.Gname = 0, 0, "", ""
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(&progressWriter{w: tw, progress: progress, total: &total,
+			p: CopyProgress{File: header.Name, Size: header.Size}}, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// progressWriter 写入时更新进度
+type progressWriter struct {
+	w        io.Writer
+	progress func(CopyProgress)
+	total    *int64
+	p        CopyProgress
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	if n > 0 && w.progress != nil {
+		*w.total += int64(n)
+		w.p.Written += int64(n)
+		w.p.Total = *w.total
+		w.progress(w.p)
+	}
+	return n, err
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+func exitError(code int) error {
+	return utilexec.CodeExitError{Err: fmt.Errorf("command terminated with exit code %d", code), Code: code}
+}
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	body     string
+	link     string
+}
+
+func buildTar(entries ...tarEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		tw.WriteHeader(&tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: e.mode, Size: int64(len(e.body)), Linkname: e.link})
+		io.WriteString(tw, e.body)
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func TestCopyFromPod(t *testing.T) {
+	c := NewFakeClientSet()
+	archive := buildTar(
+		tarEntry{name: "dumps/", typeflag: tar.TypeDir, mode: 0750},
+		tarEntry{name: "dumps/heap.hprof", typeflag: tar.TypeReg, mode: 0600, body: "heap"},
+		tarEntry{name: "dumps/bin/run.sh", typeflag: tar.TypeReg, mode: 0755, body: "#!/bin/sh"},
+		tarEntry{name: "dumps/passwd", typeflag: tar.TypeSymlink, link: "/etc/passwd"},
+	)
+	e := useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		_, err := o.Stdout.Write(archive)
+		return err
+	})
+
+	dest := filepath.Join(t.TempDir(), "local")
+	var progress []CopyProgress
+	err := c.CopyFromPod("default", "api-0", "/tmp/dumps/", dest, CopyOpts{
+		Container: "api",
+		Progress:  func(p CopyProgress) { progress = append(progress, p) },
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"tar", "cf", "-", "-C", "/tmp", "--", "dumps"}, e.url.Query()["command"])
+
+	data, err := os.ReadFile(filepath.Join(dest, "heap.hprof"))
+	assert.NoError(t, err)
+	assert.Equal(t, "heap", string(data))
+	info, err := os.Stat(filepath.Join(dest, "bin", "run.sh"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	info, err = os.Stat(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0750), info.Mode().Perm())
+	_, err = os.Lstat(filepath.Join(dest, "passwd"))
+	assert.True(t, os.IsNotExist(err))
+	assert.EqualValues(t, []CopyProgress{
+		{File: "dumps/heap.hprof", Size: 4, Written: 4, Total: 4},
+		{File: "dumps/bin/run.sh", Size: 9, Written: 9, Total: 13},
+	}, progress)
+
+	for _, name := range []string{"dumps/../../evil", "/etc/cron.d/evil", "other/evil"} {
+		archive = buildTar(tarEntry{name: name, typeflag: tar.TypeReg, mode: 0644, body: "evil"})
+		root := t.TempDir()
+		err = c.CopyFromPod("default", "api-0", "/tmp/dumps", filepath.Join(root, "local"), CopyOpts{Container: "api"})
+		assert.Error(t, err, name)
+		assert.Contains(t, err.Error(), "outside", name)
+		_, err = os.Stat(filepath.Join(root, "evil"))
+		assert.True(t, os.IsNotExist(err), name)
+	}
+
+	useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		io.WriteString(o.Stderr, "tar: dumps: No such file or directory\n")
+		return exitError(2)
+	})
+	err = c.CopyFromPod("default", "api-0", "/tmp/dumps", t.TempDir(), CopyOpts{Container: "api"})
+	assert.True(t, IsExecFailed(err))
+	assert.Contains(t, err.Error(), "No such file or directory")
+
+	assert.True(t, IsInvalid(c.CopyFromPod("default", "api-0", "/", t.TempDir(), CopyOpts{Container: "api"})))
+
+	// 以 - 开头的路径不能被 tar 当作选项
+	e = useFakeExecutor(t, func(o remotecommand.StreamOptions) error { return nil })
+	assert.NoError(t, c.CopyFromPod("default", "api-0", "--checkpoint=1/--to-command=sh", t.TempDir(), CopyOpts{Container: "api"}))
+	assert.EqualValues(t, []string{"tar", "cf", "-", "-C", "./--checkpoint=1", "--", "--to-command=sh"}, e.url.Query()["command"])
+}
+
+func TestCopyToPod(t *testing.T) {
+	c := NewFakeClientSet()
+	src := filepath.Join(t.TempDir(), "conf")
+	assert.NoError(t, os.MkdirAll(filepath.Join(src, "conf.d"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "app.yaml"), []byte("port: 80"), 0640))
+	assert.NoError(t, os.WriteFile(filepath.Join(src, "conf.d", "extra.yaml"), []byte("debug: true"), 0600))
+	assert.NoError(t, os.Symlink("/etc/passwd", filepath.Join(src, "passwd")))
+
+	files := map[string]string{}
+	modes := map[string]int64{}
+	e := useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		tr := tar.NewReader(o.Stdin)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			data, _ := io.ReadAll(tr)
+			files[header.Name] = string(data)
+			modes[header.Name] = header.Mode & 0777
+		}
+	})
+
+	var total int64
+	err := c.CopyToPod("default", "api-0", src, "/etc/app/config", CopyOpts{
+		Container: "api",
+		Progress:  func(p CopyProgress) { total = p.Total },
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"tar", "xmf", "-", "-C", "/etc/app"}, e.url.Query()["command"])
+	assert.EqualValues(t, map[string]string{
+		"config":                   "",
+		"config/app.yaml":          "port: 80",
+		"config/conf.d":            "",
+		"config/conf.d/extra.yaml": "debug: true",
+	}, files)
+	assert.EqualValues(t, 0640, modes["config/app.yaml"])
+	assert.EqualValues(t, 0600, modes["config/conf.d/extra.yaml"])
+	assert.EqualValues(t, len("port: 80")+len("debug: true"), total)
+
+	// 容器中的 tar 读取部分数据后退出
+	useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		o.Stdin.Read(make([]byte, 1))
+		io.WriteString(o.Stderr, "tar: can't create directory: Read-only file system\n")
+		return exitError(1)
+	})
+	err = c.CopyToPod("default", "api-0", src, "/etc/app/config", CopyOpts{Container: "api"})
+	assert.True(t, IsExecFailed(err))
+	assert.True(t, strings.Contains(err.Error(), "Read-only file system"))
+
+	assert.True(t, IsInvalid(c.CopyToPod("default", "api-0", filepath.Join(src, "missing"), "/tmp/x", CopyOpts{Container: "api"})))
+
+	e = useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		_, err := io.Copy(io.Discard, o.Stdin)
+		return err
+	})
+	assert.NoError(t, c.CopyToPod("default", "api-0", src, "--to-command=sh/config", CopyOpts{Container: "api"}))
+	assert.EqualValues(t, []string{"tar", "xmf", "-", "-C", "./--to-command=sh"}, e.url.Query()["command"])
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zhengyansheng/common"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// DaemonSetCreate 创建 daemonSet
+func (c *clientSetClient) DaemonSetCreate(namespace string, daemonSet *appsv1.DaemonSet) (*appsv1.DaemonSet, error) {
+	return c.DaemonSetCreateContext(c.defaultContext(), namespace, daemonSet)
+}
+
+func (c *clientSetClient) DaemonSetCreateContext(ctx context.Context, namespace string, daemonSet *appsv1.DaemonSet) (*appsv1.DaemonSet, error) {
+	ds, err := c.ClientSet.AppsV1().DaemonSets(namespace).Create(ctx, daemonSet, metav1.CreateOptions{})
+	return ds, c.wrapError(err, "DaemonSet", namespace, daemonSet.Name)
+}
+
+// DaemonSetGet 查询单个 daemonSet 原生数据
+func (c *clientSetClient) DaemonSetGet(namespace, name string) (*appsv1.DaemonSet, error) {
+	return c.DaemonSetGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DaemonSetGetContext(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error) {
+	ds, err := c.ClientSet.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	return ds, c.wrapError(err, "DaemonSet", namespace, name)
+}
+
+func (c *clientSetClient) DaemonSetUpdate(namespace string, daemonSet *appsv1.DaemonSet) (*appsv1.DaemonSet, error) {
+	return c.DaemonSetUpdateContext(c.defaultContext(), namespace, daemonSet)
+}
+
+func (c *clientSetClient) DaemonSetUpdateContext(ctx context.Context, namespace string, daemonSet *appsv1.DaemonSet) (*appsv1.DaemonSet, error) {
+	ds, err := c.ClientSet.AppsV1().DaemonSets(namespace).Update(ctx, daemonSet, metav1.UpdateOptions{})
+	return ds, c.wrapError(err, "DaemonSet", namespace, daemonSet.Name)
+}
+
+// DaemonSetDelete 删除 daemonSet 及其 pod
+func (c *clientSetClient) DaemonSetDelete(namespace, name string) error {
+	return c.DaemonSetDeleteContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DaemonSetDeleteContext(ctx context.Context, namespace, name string) error {
+	deletePolicy := metav1.DeletePropagationForeground
+	err := c.ClientSet.AppsV1().DaemonSets(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	})
+	return c.wrapError(err, "DaemonSet", namespace, name)
+}
+
+// DaemonSetRestart 滚动重启 daemonSet, 等同于 kubectl rollout restart
+func (c *clientSetClient) DaemonSetRestart(namespace, name string) (*appsv1.DaemonSet, error) {
+	return c.DaemonSetRestartContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DaemonSetRestartContext(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error) {
+	ds, err := c.ClientSet.AppsV1().DaemonSets(namespace).Patch(ctx, name, types.MergePatchType, restartPatch(), metav1.PatchOptions{})
+	return ds, c.wrapError(err, "DaemonSet", namespace, name)
+}
+
+// DaemonSetGetFormat 查询单个 daemonSet 格式化数据
+func (c *clientSetClient) DaemonSetGetFormat(namespace, name string) (DaemonSetInfo, error) {
+	return c.DaemonSetGetFormatContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DaemonSetGetFormatContext(ctx context.Context, namespace, name string) (detail DaemonSetInfo, err error) {
+	ds, err := c.DaemonSetGetContext(ctx, namespace, name)
+	if err != nil {
+		return
+	}
+	return daemonSetInfo(ds), nil
+}
+
+// DaemonSetListFormat 获取 daemonSet 格式化后的数据
+func (c *clientSetClient) DaemonSetListFormat(namespace string, opts ...ListOpts) ([]DaemonSetInfo, error) {
+	return c.DaemonSetListFormatContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) DaemonSetListFormatContext(ctx context.Context, namespace string, opts ...ListOpts) (daemonSets []DaemonSetInfo, err error) {
+	list, err := c.DaemonSetListContext(ctx, namespace, opts...)
+	if err != nil {
+		return
+	}
+	for i := range list.Items {
+		daemonSets = append(daemonSets, daemonSetInfo(&list.Items[i]))
+	}
+	return
+}
+
+// DaemonSetNodes 按 node 展示 daemonSet 的 pod 及其版本, 用于逐个 node 跟踪升级进度, 按 node 名称排序
+func (c *clientSetClient) DaemonSetNodes(namespace, name string) ([]DaemonSetNodePod, error) {
+	return c.DaemonSetNodesContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DaemonSetNodesContext(ctx context.Context, namespace, name string) ([]DaemonSetNodePod, error) {
+	ds, err := c.DaemonSetGetContext(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	updateRevision, err := c.daemonSetUpdateRevision(ctx, ds)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+	if err != nil {
+		return nil, NewError(ReasonInvalid, "DaemonSet", namespace, name, err)
+	}
+	podList, err := c.PodListContext(ctx, namespace, ListOpts{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []DaemonSetNodePod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if ref := metav1.GetControllerOf(pod); ref == nil || ref.UID != ds.UID {
+			continue
+		}
+		revision := pod.Labels[appsv1.DefaultDaemonSetUniqueLabelKey]
+		nodes = append(nodes, DaemonSetNodePod{
+			NodeName: pod.Spec.NodeName,
+			PodName:  pod.Name,
+			Phase:    string(pod.Status.Phase),
+			Ready:    podReady(pod),
+			Revision: revision,
+			Updated:  updateRevision != "" && revision == updateRevision,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].NodeName != nodes[j].NodeName {
+			return nodes[i].NodeName < nodes[j].NodeName
+		}
+		return nodes[i].PodName < nodes[j].PodName
+	})
+	return nodes, nil
+}
+
+// daemonSetUpdateRevision 返回 daemonSet 最新 ControllerRevision 的 hash, 与 pod 的 controller-revision-hash 标签对应
+func (c *clientSetClient) daemonSetUpdateRevision(ctx context.Context, ds *appsv1.DaemonSet) (string, error) {
+	selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+	if err != nil {
+		return "", NewError(ReasonInvalid, "DaemonSet", ds.Namespace, ds.Name, err)
+	}
+	list, err := c.ClientSet.AppsV1().ControllerRevisions(ds.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return "", c.wrapError(err, "ControllerRevision", ds.Namespace, "")
+	}
+	var latest *appsv1.ControllerRevision
+	for i := range list.Items {
+		cr := &list.Items[i]
+		if ref := metav1.GetControllerOf(cr); ref == nil || ref.UID != ds.UID {
+			continue
+		}
+		if latest == nil || cr.Revision > latest.Revision {
+			latest = cr
+		}
+	}
+	if latest == nil {
+		return "", nil
+	}
+	if hash, ok := latest.Labels[appsv1.DefaultDaemonSetUniqueLabelKey]; ok {
+		return hash, nil
+	}
+	return strings.TrimPrefix(latest.Name, ds.Name+"-"), nil
+}
+
+func daemonSetInfo(ds *appsv1.DaemonSet) DaemonSetInfo {
+	createTime := ds.CreationTimestamp.Format(common.SecLocalTimeFormat)
+	currentTime := time.Now().Format(common.SecLocalTimeFormat)
+	info := DaemonSetInfo{
+		Name:           ds.Name,
+		Age:            common.SubTime(createTime, currentTime),
+		Desired:        ds.Status.DesiredNumberScheduled,
+		Current:        ds.Status.CurrentNumberScheduled,
+		Ready:          ds.Status.NumberReady,
+		Updated:        ds.Status.UpdatedNumberScheduled,
+		Available:      ds.Status.NumberAvailable,
+		Misscheduled:   ds.Status.NumberMisscheduled,
+		UpdateStrategy: string(ds.Spec.UpdateStrategy.Type),
+		NodeSelector:   ds.Spec.Template.Spec.NodeSelector,
+	}
+	switch {
+	case info.Updated != info.Desired:
+		info.Phase = "Updating"
+	case info.Ready != info.Desired || info.Misscheduled > 0:
+		info.Phase = "Progressing"
+	default:
+		info.Phase = "Healthy"
+	}
+	return info
+}
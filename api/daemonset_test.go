@@ -0,0 +1,71 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDaemonSetNodes(t *testing.T) {
+	isController := true
+	owner := []metav1.OwnerReference{{Kind: "DaemonSet", Name: "node-agent", UID: types.UID("ds-uid"), Controller: &isController}}
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-agent", Namespace: "kube-system", UID: types.UID("ds-uid")},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "node-agent"}},
+		},
+		Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, CurrentNumberScheduled: 2, NumberReady: 2, UpdatedNumberScheduled: 1},
+	}
+	revision := func(name, hash string, rev int64) *appsv1.ControllerRevision {
+		return &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "kube-system",
+				Labels:          map[string]string{"app": "node-agent", appsv1.DefaultDaemonSetUniqueLabelKey: hash},
+				OwnerReferences: owner,
+			},
+			Revision: rev,
+		}
+	}
+	pod := func(name, node, hash string) *apiv1.Pod {
+		return &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "kube-system",
+				Labels:          map[string]string{"app": "node-agent", appsv1.DefaultDaemonSetUniqueLabelKey: hash},
+				OwnerReferences: owner,
+			},
+			Spec:   apiv1.PodSpec{NodeName: node},
+			Status: apiv1.PodStatus{Phase: apiv1.PodRunning, Conditions: []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}},
+		}
+	}
+	c := NewFakeClientSet(
+		ds,
+		revision("node-agent-aaa", "aaa", 1),
+		revision("node-agent-bbb", "bbb", 2),
+		pod("node-agent-x1", "node-2", "aaa"),
+		pod("node-agent-x2", "node-1", "bbb"),
+	)
+
+	info, err := c.DaemonSetGetFormat("kube-system", "node-agent")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, info.Desired)
+	assert.EqualValues(t, "Updating", info.Phase)
+
+	nodes, err := c.DaemonSetNodes("kube-system", "node-agent")
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+	assert.EqualValues(t, "node-1", nodes[0].NodeName)
+	assert.True(t, nodes[0].Updated)
+	assert.EqualValues(t, "node-2", nodes[1].NodeName)
+	assert.EqualValues(t, "aaa", nodes[1].Revision)
+	assert.False(t, nodes[1].Updated)
+
+	updated, err := c.DaemonSetRestart("kube-system", "node-agent")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, updated.Spec.Template.Annotations[restartedAtAnnotation])
+}
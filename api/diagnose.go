@@ -0,0 +1,437 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/events/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const (
+	SeverityCritical = "Critical" // pod 无法运行或持续重启
+	SeverityWarning  = "Warning"  // pod 可以运行但存在隐患, 如探针失败、曾经 OOM
+)
+
+// Finding 一条诊断结论
+type Finding struct {
+	Severity  string `json:"severity"`
+	Reason    string `json:"reason"`
+	Container string `json:"container,omitempty"`
+	Message   string `json:"message"`
+	Remedy    string `json:"remedy"`
+}
+
+// PodDiagnosis DiagnosePod 的结果, Findings 按严重程度排序
+type PodDiagnosis struct {
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Status    PodStatus `json:"status"`
+	Healthy   bool      `json:"healthy"`
+	Findings  []Finding `json:"findings"`
+}
+
+// DiagnosePod 综合容器状态、上次退出原因、事件、调度条件以及引用的 ConfigMap/Secret/PVC,
+// 解释 pod 不健康的原因并给出处理建议
+func (c *clientSetClient) DiagnosePod(namespace, name string) (*PodDiagnosis, error) {
+	return c.DiagnosePodContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) DiagnosePodContext(ctx context.Context, namespace, name string) (*PodDiagnosis, error) {
+	pod, err := c.PodGetContext(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	selector := fields.Set{"regarding.kind": "Pod", "regarding.name": name}.AsSelector().String()
+	eventList, err := c.EventsListContext(ctx, namespace, ListOpts{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	var events []v1beta1.Event
+	for _, e := range eventList.Items {
+		if e.Regarding.Kind != "Pod" || e.Regarding.Name != name || (e.Regarding.UID != "" && e.Regarding.UID != pod.UID) {
+			continue
+		}
+		events = append(events, e)
+	}
+
+	d := &diagnoser{pod: pod, events: events, seen: map[string]bool{}}
+	d.scheduling()
+	d.containers()
+	d.probes()
+	d.volumeEvents()
+	d.podPhase()
+	if err = c.diagnoseReferences(ctx, d); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(d.findings, func(i, j int) bool {
+		return d.findings[i].Severity == SeverityCritical && d.findings[j].Severity != SeverityCritical
+	})
+	return &PodDiagnosis{
+		Namespace: namespace,
+		Pod:       name,
+		Status:    podDisplayStatus(pod),
+		Healthy:   len(d.findings) == 0,
+		Findings:  d.findings,
+	}, nil
+}
+
+type diagnoser struct {
+	pod      *apiv1.Pod
+	events   []v1beta1.Event
+	findings []Finding
+	seen     map[string]bool
+}
+
+// add 同一容器的同一原因只保留第一条
+func (d *diagnoser) add(f Finding) {
+	key := f.Reason + "/" + f.Container
+	if d.seen[key] {
+		return
+	}
+	d.seen[key] = true
+	d.findings = append(d.findings, f)
+}
+
+// eventNote 返回最近一条匹配 reason 且 note 包含 contains 的事件说明
+func (d *diagnoser) eventNote(reason, contains string) string {
+	var latest *v1beta1.Event
+	for i := range d.events {
+		e := &d.events[i]
+		if e.Reason != reason || !strings.Contains(e.Note, contains) {
+			continue
+		}
+		if latest == nil || eventTime(e).After(eventTime(latest)) {
+			latest = e
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return latest.Note
+}
+
+// eventTime 兼容 core/v1 写入的事件, 它们只有 deprecatedLastTimestamp
+func eventTime(e *v1beta1.Event) time.Time {
+	switch {
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	case !e.DeprecatedLastTimestamp.IsZero():
+		return e.DeprecatedLastTimestamp.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+// scheduling 解析 PodScheduled=False 的原因, 如 0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had taint ...
+func (d *diagnoser) scheduling() {
+	var message string
+	for _, cond := range d.pod.Status.Conditions {
+		if cond.Type == apiv1.PodScheduled && cond.Status == apiv1.ConditionFalse {
+			message = cond.Message
+		}
+	}
+	if message == "" && d.pod.Spec.NodeName == "" {
+		message = d.eventNote("FailedScheduling", "")
+	}
+	if message == "" {
+		return
+	}
+
+	matched := false
+	check := func(substr, reason, remedy string) {
+		if strings.Contains(message, substr) {
+			matched = true
+			d.add(Finding{Severity: SeverityCritical, Reason: reason, Message: message, Remedy: remedy})
+		}
+	}
+	check("Insufficient cpu", "InsufficientCPU",
+		"no node has enough unrequested cpu, lower resources.requests.cpu or add nodes")
+	check("Insufficient memory", "InsufficientMemory",
+		"no node has enough unrequested memory, lower resources.requests.memory or add nodes")
+	check("Insufficient pods", "TooManyPods", "nodes reached their pod limit, add nodes")
+	check("had taint", "UntoleratedTaint",
+		"add matching tolerations to the pod or remove the taint from the nodes")
+	check("didn't match Pod's node affinity", "NodeAffinityMismatch",
+		"no node matches nodeSelector/nodeAffinity, check the node labels against the pod selector")
+	check("didn't match pod affinity", "PodAffinityMismatch",
+		"no node satisfies podAffinity/podAntiAffinity, relax the rules or add nodes")
+	check("unbound immediate PersistentVolumeClaims", "UnboundPersistentVolumeClaim",
+		"the pod's PVC is not bound, check that its StorageClass exists and the provisioner is healthy")
+	check("node(s) were unschedulable", "NodeUnschedulable", "nodes are cordoned, uncordon them or add nodes")
+	if !matched {
+		d.add(Finding{Severity: SeverityCritical, Reason: "Unschedulable", Message: message,
+			Remedy: "adjust the pod's resources, affinity or tolerations according to the scheduler message"})
+	}
+}
+
+func (d *diagnoser) containers() {
+	specs := map[string]apiv1.Container{}
+	for _, container := range append(append([]apiv1.Container{}, d.pod.Spec.InitContainers...), d.pod.Spec.Containers...) {
+		specs[container.Name] = container
+	}
+	statuses := append(append([]apiv1.ContainerStatus{}, d.pod.Status.InitContainerStatuses...), d.pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		d.container(specs[status.Name], status)
+	}
+}
+
+func (d *diagnoser) container(spec apiv1.Container, status apiv1.ContainerStatus) {
+	name := status.Name
+	last := status.LastTerminationState.Terminated
+	if current := status.State.Terminated; current != nil && current.ExitCode != 0 {
+		last = current
+	}
+
+	if waiting := status.State.Waiting; waiting != nil {
+		switch PodStatus(waiting.Reason) {
+		case CrashLoopBackOff:
+			msg := fmt.Sprintf("container %s keeps crashing, restarted %d times", name, status.RestartCount)
+			if last != nil {
+				msg += fmt.Sprintf(", last termination: %s", terminationSummary(last))
+			}
+			d.add(Finding{Severity: SeverityCritical, Reason: string(CrashLoopBackOff), Container: name,
+				Message: msg, Remedy: exitCodeRemedy(last)})
+		case ImagePullBackOff, ErrImagePull, RegistryUnavailable:
+			note := d.eventNote("Failed", spec.Image)
+			if note == "" {
+				note = waiting.Message
+			}
+			d.add(Finding{Severity: SeverityCritical, Reason: waiting.Reason, Container: name,
+				Message: fmt.Sprintf("failed to pull image %s: %s", spec.Image, note),
+				Remedy:  imagePullRemedy(note)})
+		case InvalidImageName:
+			d.add(Finding{Severity: SeverityCritical, Reason: waiting.Reason, Container: name,
+				Message: fmt.Sprintf("invalid image name %s: %s", spec.Image, waiting.Message),
+				Remedy:  "fix the image reference, the format is registry/repository:tag"})
+		case ErrImageNeverPull:
+			d.add(Finding{Severity: SeverityCritical, Reason: waiting.Reason, Container: name,
+				Message: fmt.Sprintf("imagePullPolicy is Never and image %s is not present on the node", spec.Image),
+				Remedy:  "set imagePullPolicy to IfNotPresent or preload the image on the node"})
+		case CreateContainerConfigError:
+			d.add(Finding{Severity: SeverityCritical, Reason: waiting.Reason, Container: name,
+				Message: fmt.Sprintf("container %s config error: %s", name, waiting.Message),
+				Remedy:  "check that the ConfigMaps/Secrets and keys referenced by the container exist"})
+		case CreateContainerError, RunContainerError, PostStartHookError:
+			d.add(Finding{Severity: SeverityCritical, Reason: waiting.Reason, Container: name,
+				Message: fmt.Sprintf("container %s failed to start: %s", name, waiting.Message),
+				Remedy:  "check command/args, that the executable exists in the image, and the postStart hook"})
+		}
+	}
+
+	if last != nil && last.Reason == "OOMKilled" {
+		limit := "none"
+		if quantity, ok := spec.Resources.Limits[apiv1.ResourceMemory]; ok {
+			limit = quantity.String()
+		}
+		d.add(Finding{Severity: SeverityWarning, Reason: "OOMKilled", Container: name,
+			Message: fmt.Sprintf("container %s was killed for exceeding its memory limit (%s), restarted %d times", name, limit, status.RestartCount),
+			Remedy:  "raise resources.limits.memory or look for a memory leak"})
+	}
+}
+
+// probes 从 Unhealthy 事件中找出探针失败
+func (d *diagnoser) probes() {
+	if note := d.eventNote("Unhealthy", "Liveness probe failed"); note != "" {
+		d.add(Finding{Severity: SeverityWarning, Reason: "LivenessProbeFailed", Message: note,
+			Remedy: "failing liveness probes restart the container, check the probe path/port and raise initialDelaySeconds or failureThreshold"})
+	}
+	for _, status := range d.pod.Status.ContainerStatuses {
+		if status.Ready || status.State.Running == nil {
+			continue
+		}
+		message := fmt.Sprintf("container %s is running but not ready", status.Name)
+		if note := d.eventNote("Unhealthy", "Readiness probe failed"); note != "" {
+			message += ": " + note
+		}
+		d.add(Finding{Severity: SeverityWarning, Reason: "ReadinessProbeFailed", Container: status.Name, Message: message,
+			Remedy: "pods that are not ready receive no Service traffic, check the readiness probe and the application's dependencies"})
+	}
+}
+
+func (d *diagnoser) volumeEvents() {
+	for _, reason := range []string{"FailedMount", "FailedAttachVolume"} {
+		if note := d.eventNote(reason, ""); note != "" && d.pod.Status.Phase == apiv1.PodPending {
+			d.add(Finding{Severity: SeverityCritical, Reason: reason, Message: note,
+				Remedy: "check that the volumes exist and the PV is not still attached to another node"})
+		}
+	}
+}
+
+func (d *diagnoser) podPhase() {
+	switch {
+	case d.pod.Status.Reason == "Evicted":
+		d.add(Finding{Severity: SeverityCritical, Reason: "Evicted", Message: d.pod.Status.Message,
+			Remedy: "the node ran out of resources, set realistic requests and clean up evicted pods"})
+	case d.pod.DeletionTimestamp != nil && len(d.pod.Finalizers) > 0:
+		d.add(Finding{Severity: SeverityWarning, Reason: string(Terminating),
+			Message: fmt.Sprintf("pod is being deleted, waiting for finalizers: %s", strings.Join(d.pod.Finalizers, ", ")),
+			Remedy:  "check that the controllers owning the finalizers are running, remove the finalizers manually if needed"})
+	}
+}
+
+// diagnoseReferences 检查 pod 引用的 ConfigMap/Secret/PVC 是否存在, optional 的引用不检查
+func (c *clientSetClient) diagnoseReferences(ctx context.Context, d *diagnoser) error {
+	namespace := d.pod.Namespace
+	configMaps, secrets, pvcs, pullSecrets := podReferences(d.pod)
+
+	for _, name := range configMaps {
+		_, err := c.ClientSet.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = c.wrapError(err, "ConfigMap", namespace, name); IsNotFound(err) {
+			d.add(Finding{Severity: SeverityCritical, Reason: "MissingConfigMap",
+				Message: fmt.Sprintf("referenced ConfigMap %s does not exist", name),
+				Remedy:  fmt.Sprintf("create ConfigMap %s in namespace %s or mark the reference optional", name, namespace)})
+		} else if err != nil && !IsForbidden(err) {
+			return err
+		}
+	}
+	for _, name := range secrets {
+		_, err := c.ClientSet.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = c.wrapError(err, "Secret", namespace, name); IsNotFound(err) {
+			d.add(Finding{Severity: SeverityCritical, Reason: "MissingSecret",
+				Message: fmt.Sprintf("referenced Secret %s does not exist", name),
+				Remedy:  fmt.Sprintf("create Secret %s in namespace %s or mark the reference optional", name, namespace)})
+		} else if err != nil && !IsForbidden(err) {
+			return err
+		}
+	}
+	// kubelet 拉取镜像时会跳过不存在的凭证, 公开镜像仍可拉取, 所以只是 Warning
+	for _, name := range pullSecrets {
+		_, err := c.ClientSet.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = c.wrapError(err, "Secret", namespace, name); IsNotFound(err) {
+			d.add(Finding{Severity: SeverityWarning, Reason: "MissingImagePullSecret",
+				Message: fmt.Sprintf("image pull secret %s does not exist", name),
+				Remedy: fmt.Sprintf("create Secret %s in namespace %s, or remove it from imagePullSecrets of the pod or its ServiceAccount %s",
+					name, namespace, d.pod.Spec.ServiceAccountName)})
+		} else if err != nil && !IsForbidden(err) {
+			return err
+		}
+	}
+	for _, name := range pvcs {
+		pvc, err := c.ClientSet.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		err = c.wrapError(err, "PersistentVolumeClaim", namespace, name)
+		switch {
+		case IsNotFound(err):
+			d.add(Finding{Severity: SeverityCritical, Reason: "MissingPersistentVolumeClaim",
+				Message: fmt.Sprintf("referenced PVC %s does not exist", name),
+				Remedy:  fmt.Sprintf("create PVC %s in namespace %s", name, namespace)})
+		case err != nil && !IsForbidden(err):
+			return err
+		case err == nil && pvc.Status.Phase == apiv1.ClaimPending:
+			d.add(Finding{Severity: SeverityCritical, Reason: "PendingPersistentVolumeClaim",
+				Message: fmt.Sprintf("PVC %s is not bound to a PV", name),
+				Remedy:  "check that the PVC's StorageClass exists and the provisioner is healthy, or create a matching PV"})
+		}
+	}
+	return nil
+}
+
+// podReferences 返回 pod 必须存在的 ConfigMap/Secret/PVC 名称以及 imagePullSecrets, 已去重
+func podReferences(pod *apiv1.Pod) (configMaps, secrets, pvcs, pullSecrets []string) {
+	seen := map[string]bool{}
+	add := func(list *[]string, kind, name string, optional *bool) {
+		if name == "" || (optional != nil && *optional) || seen[kind+"/"+name] {
+			return
+		}
+		seen[kind+"/"+name] = true
+		*list = append(*list, name)
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			add(&configMaps, "ConfigMap", volume.ConfigMap.Name, volume.ConfigMap.Optional)
+		case volume.Secret != nil:
+			add(&secrets, "Secret", volume.Secret.SecretName, volume.Secret.Optional)
+		case volume.PersistentVolumeClaim != nil:
+			add(&pvcs, "PVC", volume.PersistentVolumeClaim.ClaimName, nil)
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					add(&configMaps, "ConfigMap", source.ConfigMap.Name, source.ConfigMap.Optional)
+				}
+				if source.Secret != nil {
+					add(&secrets, "Secret", source.Secret.Name, source.Secret.Optional)
+				}
+			}
+		}
+	}
+	for _, container := range append(append([]apiv1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		for _, env := range container.EnvFrom {
+			if env.ConfigMapRef != nil {
+				add(&configMaps, "ConfigMap", env.ConfigMapRef.Name, env.ConfigMapRef.Optional)
+			}
+			if env.SecretRef != nil {
+				add(&secrets, "Secret", env.SecretRef.Name, env.SecretRef.Optional)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				add(&configMaps, "ConfigMap", ref.Name, ref.Optional)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				add(&secrets, "Secret", ref.Name, ref.Optional)
+			}
+		}
+	}
+	for _, ref := range pod.Spec.ImagePullSecrets {
+		add(&pullSecrets, "ImagePullSecret", ref.Name, nil)
+	}
+	return
+}
+
+func terminationSummary(t *apiv1.ContainerStateTerminated) string {
+	s := fmt.Sprintf("reason=%s exitCode=%d", t.Reason, t.ExitCode)
+	if t.Signal != 0 {
+		s += fmt.Sprintf(" signal=%d", t.Signal)
+	}
+	if t.Message != "" {
+		s += ", " + t.Message
+	}
+	return s
+}
+
+// exitCodeRemedy 根据退出码给出排查建议
+func exitCodeRemedy(t *apiv1.ContainerStateTerminated) string {
+	if t == nil {
+		return "check the previous container logs for the startup failure"
+	}
+	switch {
+	case t.Reason == "OOMKilled":
+		return "the container exceeded its memory limit, raise resources.limits.memory or look for a memory leak"
+	case t.ExitCode == 126:
+		return "the command is not executable, check the file permissions of command"
+	case t.ExitCode == 127:
+		return "the command was not found, check command/args and that the image contains the executable"
+	case t.ExitCode == 137:
+		return "the container was killed by SIGKILL, usually a failing liveness probe or memory pressure"
+	case t.ExitCode == 143:
+		return "the container exited on SIGTERM, check whether the liveness probe is too strict"
+	default:
+		return "the application exited with an error, check the previous container logs"
+	}
+}
+
+func imagePullRemedy(note string) string {
+	lower := strings.ToLower(note)
+	switch {
+	case strings.Contains(lower, "unauthorized"), strings.Contains(lower, "authentication required"),
+		strings.Contains(lower, "denied"):
+		return "registry authentication failed, check that imagePullSecrets are set and the credentials are valid"
+	case strings.Contains(lower, "not found"), strings.Contains(lower, "manifest unknown"):
+		return "the image or tag does not exist, check the image name and tag"
+	case strings.Contains(lower, "timeout"), strings.Contains(lower, "no such host"), strings.Contains(lower, "connection refused"):
+		return "the node cannot reach the registry, check network, DNS and the registry address"
+	default:
+		return "check the image name, tag and imagePullSecrets"
+	}
+}
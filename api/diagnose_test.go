@@ -0,0 +1,174 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/events/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDiagnosePod(t *testing.T) {
+	optional := true
+	event := func(name, reason, note string) *v1beta1.Event {
+		return &v1beta1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "." + reason, Namespace: "default"},
+			Regarding:  apiv1.ObjectReference{Kind: "Pod", Name: name, Namespace: "default"},
+			Reason:     reason,
+			Note:       note,
+		}
+	}
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		expect  []string
+		healthy bool
+	}{
+		{
+			name: "healthy",
+			objects: []runtime.Object{&apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "app", Image: "nginx"}}},
+				Status: apiv1.PodStatus{Phase: apiv1.PodRunning, ContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "app", Ready: true, State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}}},
+				}},
+			}},
+			healthy: true,
+		},
+		{
+			name: "crash loop after oom",
+			objects: []runtime.Object{&apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Spec: apiv1.PodSpec{Containers: []apiv1.Container{{
+					Name:      "app",
+					Resources: apiv1.ResourceRequirements{Limits: apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("256Mi")}},
+				}}},
+				Status: apiv1.PodStatus{Phase: apiv1.PodRunning, ContainerStatuses: []apiv1.ContainerStatus{{
+					Name:                 "app",
+					RestartCount:         5,
+					State:                apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137}},
+				}}},
+			}},
+			expect: []string{"CrashLoopBackOff", "OOMKilled"},
+		},
+		{
+			name: "image pull unauthorized",
+			objects: []runtime.Object{
+				&apiv1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+					Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "app", Image: "registry.example.com/team/app:v1"}}},
+					Status: apiv1.PodStatus{Phase: apiv1.PodPending, ContainerStatuses: []apiv1.ContainerStatus{{
+						Name:  "app",
+						State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+					}}},
+				},
+				event("app", "Failed", `Failed to pull image "registry.example.com/team/app:v1": 401 Unauthorized`),
+			},
+			expect: []string{"ImagePullBackOff"},
+		},
+		{
+			name: "unschedulable",
+			objects: []runtime.Object{&apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+				Status: apiv1.PodStatus{Phase: apiv1.PodPending, Conditions: []apiv1.PodCondition{{
+					Type:    apiv1.PodScheduled,
+					Status:  apiv1.ConditionFalse,
+					Reason:  "Unschedulable",
+					Message: "0/3 nodes are available: 1 Insufficient cpu, 2 node(s) had taint {gpu: }, that the pod didn't tolerate.",
+				}}},
+			}},
+			expect: []string{"InsufficientCPU", "UntoleratedTaint"},
+		},
+		{
+			name: "missing references",
+			objects: []runtime.Object{
+				&apiv1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+					Spec: apiv1.PodSpec{
+						Volumes: []apiv1.Volume{
+							{Name: "config", VolumeSource: apiv1.VolumeSource{ConfigMap: &apiv1.ConfigMapVolumeSource{
+								LocalObjectReference: apiv1.LocalObjectReference{Name: "app-config"}}}},
+							{Name: "data", VolumeSource: apiv1.VolumeSource{PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}},
+						},
+						Containers: []apiv1.Container{{Name: "app", Env: []apiv1.EnvVar{
+							{Name: "PASSWORD", ValueFrom: &apiv1.EnvVarSource{SecretKeyRef: &apiv1.SecretKeySelector{
+								LocalObjectReference: apiv1.LocalObjectReference{Name: "db"}, Key: "password"}}},
+							{Name: "FEATURE", ValueFrom: &apiv1.EnvVarSource{ConfigMapKeyRef: &apiv1.ConfigMapKeySelector{
+								LocalObjectReference: apiv1.LocalObjectReference{Name: "features"}, Key: "flag", Optional: &optional}}},
+						}}},
+					},
+					Status: apiv1.PodStatus{Phase: apiv1.PodPending, ContainerStatuses: []apiv1.ContainerStatus{{
+						Name: "app",
+						State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{
+							Reason: "CreateContainerConfigError", Message: `secret "db" not found`}},
+					}}},
+				},
+				&apiv1.PersistentVolumeClaim{
+					ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "default"},
+					Status:     apiv1.PersistentVolumeClaimStatus{Phase: apiv1.ClaimPending},
+				},
+			},
+			expect: []string{"CreateContainerConfigError", "MissingConfigMap", "MissingSecret", "PendingPersistentVolumeClaim"},
+		},
+		{
+			name: "missing image pull secret",
+			objects: []runtime.Object{
+				&apiv1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+					Spec: apiv1.PodSpec{
+						Containers:         []apiv1.Container{{Name: "app", Image: "nginx"}},
+						ImagePullSecrets:   []apiv1.LocalObjectReference{{Name: "regcred"}, {Name: "harbor"}},
+						ServiceAccountName: "default",
+					},
+					Status: apiv1.PodStatus{Phase: apiv1.PodRunning, ContainerStatuses: []apiv1.ContainerStatus{
+						{Name: "app", Ready: true, State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}}},
+					}},
+				},
+				&apiv1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "harbor", Namespace: "default"}},
+			},
+			expect: []string{"MissingImagePullSecret"},
+		},
+		{
+			name: "readiness probe failing",
+			objects: []runtime.Object{
+				&apiv1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+					Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "app"}}},
+					Status: apiv1.PodStatus{Phase: apiv1.PodRunning, ContainerStatuses: []apiv1.ContainerStatus{
+						{Name: "app", State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}}},
+					}},
+				},
+				event("app", "Unhealthy", "Readiness probe failed: HTTP probe failed with statuscode: 503"),
+				event("other", "Unhealthy", "Liveness probe failed: connection refused"),
+			},
+			expect: []string{"ReadinessProbeFailed"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewFakeClientSet(test.objects...)
+			d, err := c.DiagnosePod("default", "app")
+			assert.NoError(t, err)
+			assert.EqualValues(t, test.healthy, d.Healthy)
+			var reasons []string
+			for _, f := range d.Findings {
+				reasons = append(reasons, f.Reason)
+				assert.NotEmpty(t, f.Remedy)
+				if f.Reason == "MissingImagePullSecret" {
+					assert.EqualValues(t, SeverityWarning, f.Severity)
+					assert.Contains(t, f.Remedy, "imagePullSecrets")
+				}
+			}
+			assert.EqualValues(t, test.expect, reasons)
+		})
+	}
+
+	c := NewFakeClientSet()
+	_, err := c.DiagnosePod("default", "missing")
+	assert.True(t, IsNotFound(err))
+}
@@ -19,23 +19,47 @@ import (
 
 type dynamicClient struct {
 	DynamicClient   dynamic.Interface
-	DiscoveryClient *discovery.DiscoveryClient
+	DiscoveryClient discovery.DiscoveryInterface
 	KubeConfig      *rest.Config
+	Cluster         string // 集群名称, 仅用于错误信息
 }
 
 // NewDynamicClient 初始化 dynamic client
-func NewDynamicClient(kubeConfig string) (dc *dynamicClient, err error) {
-	c, err := GetK8sDiscoveryClient(kubeConfig)
+func NewDynamicClient(kubeConfig string, opts ...Option) (dc *dynamicClient, err error) {
+	cfg, err := initClient(kubeConfig, opts...)
 	if err != nil {
 		return
 	}
+	return NewDynamicClientFromConfig(cfg)
+}
+
+// NewDynamicClientFromConfig 通过 rest.Config 初始化 dynamic client
+func NewDynamicClientFromConfig(cfg *rest.Config, opts ...Option) (*dynamicClient, error) {
+	cfg, err := applyOptions(cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	disc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
 	return &dynamicClient{
-		DynamicClient: c,
+		DynamicClient:   c,
+		DiscoveryClient: disc,
+		KubeConfig:      cfg,
 	}, nil
 }
 
 // Create create a kind resource
 func (c *dynamicClient) Create(b []byte) (*unstructured.Unstructured, error) {
+	return c.CreateContext(context.TODO(), b)
+}
+
+func (c *dynamicClient) CreateContext(ctx context.Context, b []byte) (*unstructured.Unstructured, error) {
 	u, mp, err := c.render(b)
 	if err != nil {
 		return nil, err
@@ -45,11 +69,16 @@ func (c *dynamicClient) Create(b []byte) (*unstructured.Unstructured, error) {
 		return nil, err
 	}
 	
-	return resREST.Create(context.TODO(), u, metav1.CreateOptions{})
+	obj, err := resREST.Create(ctx, u, metav1.CreateOptions{})
+	return obj, c.wrapError(err, u, u.GetName())
 }
 
 // Update update a kind resource
 func (c *dynamicClient) Update(b []byte) (*unstructured.Unstructured, error) {
+	return c.UpdateContext(context.TODO(), b)
+}
+
+func (c *dynamicClient) UpdateContext(ctx context.Context, b []byte) (*unstructured.Unstructured, error) {
 	u, mp, err := c.render(b)
 	if err != nil {
 		return nil, err
@@ -59,12 +88,17 @@ func (c *dynamicClient) Update(b []byte) (*unstructured.Unstructured, error) {
 		return nil, err
 	}
 	
-	return resREST.Update(context.TODO(), u, metav1.UpdateOptions{})
+	obj, err := resREST.Update(ctx, u, metav1.UpdateOptions{})
+	return obj, c.wrapError(err, u, u.GetName())
 	
 }
 
 // DynamicGet get crd resource
 func (c *dynamicClient) DynamicGet(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
+	return c.DynamicGetContext(context.TODO(), apiVersion, kind, namespace, name)
+}
+
+func (c *dynamicClient) DynamicGetContext(ctx context.Context, apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error) {
 	obj := &unstructured.Unstructured{
 		Object: map[string]interface{}{
 			"apiVersion": apiVersion,
@@ -76,6 +110,9 @@ func (c *dynamicClient) DynamicGet(apiVersion, kind, namespace, name string) (*u
 		},
 	}
 	bytes, err := json.Marshal(obj)
+	if err != nil {
+		return nil, NewError(ReasonParseFailed, kind, namespace, name, err)
+	}
 	u, mp, err := c.render(bytes)
 	if err != nil {
 		return nil, err
@@ -84,11 +121,16 @@ func (c *dynamicClient) DynamicGet(apiVersion, kind, namespace, name string) (*u
 	if err != nil {
 		return nil, err
 	}
-	return resREST.Get(context.TODO(), name, metav1.GetOptions{})
+	res, err := resREST.Get(ctx, name, metav1.GetOptions{})
+	return res, c.wrapError(err, u, name)
 }
 
 // Get get a kind resource
 func (c *dynamicClient) Get(b []byte, name string) (*unstructured.Unstructured, error) {
+	return c.GetContext(context.TODO(), b, name)
+}
+
+func (c *dynamicClient) GetContext(ctx context.Context, b []byte, name string) (*unstructured.Unstructured, error) {
 	u, mp, err := c.render(b)
 	if err != nil {
 		return nil, err
@@ -97,11 +139,16 @@ func (c *dynamicClient) Get(b []byte, name string) (*unstructured.Unstructured,
 	if err != nil {
 		return nil, err
 	}
-	return resREST.Get(context.TODO(), name, metav1.GetOptions{})
+	obj, err := resREST.Get(ctx, name, metav1.GetOptions{})
+	return obj, c.wrapError(err, u, name)
 }
 
 // Delete delete a kind resource
 func (c *dynamicClient) Delete(b []byte, name string) error {
+	return c.DeleteContext(context.TODO(), b, name)
+}
+
+func (c *dynamicClient) DeleteContext(ctx context.Context, b []byte, name string) error {
 	u, mp, err := c.render(b)
 	if err != nil {
 		return err
@@ -111,12 +158,16 @@ func (c *dynamicClient) Delete(b []byte, name string) error {
 		return err
 	}
 	
-	return resREST.Delete(context.TODO(), name, metav1.DeleteOptions{})
+	return c.wrapError(resREST.Delete(ctx, name, metav1.DeleteOptions{}), u, name)
 	
 }
 
 // DynamicPatch apply resource
 func (c *dynamicClient) DynamicPatch(namespace string, name string) (*unstructured.Unstructured, error) {
+	return c.DynamicPatchContext(context.TODO(), namespace, name)
+}
+
+func (c *dynamicClient) DynamicPatchContext(ctx context.Context, namespace string, name string) (*unstructured.Unstructured, error) {
 	opts := metav1.PatchOptions{}
 	unpausePatch := `{
 	"spec": {
@@ -129,7 +180,8 @@ func (c *dynamicClient) DynamicPatch(namespace string, name string) (*unstructur
 	//data := []byte(fmt.Sprintf(`{"spec":{"paused": true}}`))
 	data := []byte(unpausePatch)
 	deploymentRes := schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
-	return c.DynamicClient.Resource(deploymentRes).Namespace(namespace).Patch(context.TODO(), name, types.MergePatchType, data, opts)
+	obj, err := c.DynamicClient.Resource(deploymentRes).Namespace(namespace).Patch(ctx, name, types.MergePatchType, data, opts)
+	return obj, wrapError(err, c.Cluster, "Rollout", namespace, name)
 }
 
 func (c *dynamicClient) render(b []byte) (*unstructured.Unstructured, *meta.RESTMapping, error) {
@@ -140,7 +192,7 @@ func (c *dynamicClient) render(b []byte) (*unstructured.Unstructured, *meta.REST
 	// 获取支持的资源类型列表
 	resources, err := restmapper.GetAPIGroupResources(c.DiscoveryClient)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, wrapError(err, c.Cluster, "", "", "")
 	}
 	
 	// 创建 'Discovery REST Mapper'，获取查询的资源的类型
@@ -150,20 +202,20 @@ func (c *dynamicClient) render(b []byte) (*unstructured.Unstructured, *meta.REST
 		unstructured.UnstructuredJSONScheme).Decode(b, nil, nil)
 	
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, NewError(ReasonParseFailed, "", "", "", err)
 	}
 	
 	// 查找 Group/Version/Kind 的 REST 映射
 	mapping, err := mapper.RESTMapping(groupVersionAndKind.GroupKind(), groupVersionAndKind.Version)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, wrapError(err, c.Cluster, groupVersionAndKind.Kind, "", "")
 	}
 	
 	// 转换 yaml 的类型为 Unstructured
 	unstructuredObj, ok := runtimeObject.(*unstructured.Unstructured)
 	if !ok {
 		err = errors.New("yaml serializer can't type assertion (*unstructured.Unstructured)")
-		return nil, nil, err
+		return nil, nil, NewError(ReasonParseFailed, groupVersionAndKind.Kind, "", "", err)
 	}
 	
 	return unstructuredObj, mapping, nil
@@ -180,3 +232,7 @@ func (c *dynamicClient) resourceREST(u *unstructured.Unstructured, mp *meta.REST
 		return c.DynamicClient.Resource(mp.Resource), nil
 	}
 }
+
+func (c *dynamicClient) wrapError(err error, u *unstructured.Unstructured, name string) error {
+	return wrapError(err, c.Cluster, u.GetKind(), u.GetNamespace(), name)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const configMapYaml = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+  namespace: cn-online
+data:
+  env: online
+`
+
+func TestDynamicClient(t *testing.T) {
+	c := NewFakeDynamicClient(&apiv1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "exists", Namespace: "cn-online"},
+	})
+
+	created, err := c.Create([]byte(configMapYaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.EqualValues(t, "app-config", created.GetName())
+
+	actual, err := c.Get([]byte(configMapYaml), "app-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _, _ := unstructured.NestedString(actual.Object, "data", "env")
+	assert.EqualValues(t, "online", data)
+
+	exists, err := c.DynamicGet("v1", "ConfigMap", "cn-online", "exists")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.EqualValues(t, "exists", exists.GetName())
+
+	assert.NoError(t, c.Delete([]byte(configMapYaml), "app-config"))
+	_, err = c.Get([]byte(configMapYaml), "app-config")
+	assert.Error(t, err)
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+type ErrorReason string
+
+const (
+	ReasonUnknown       ErrorReason = "Unknown"
+	ReasonNotFound      ErrorReason = "NotFound"
+	ReasonAlreadyExists ErrorReason = "AlreadyExists"
+	ReasonConflict      ErrorReason = "Conflict"
+	ReasonForbidden     ErrorReason = "Forbidden"
+	ReasonUnauthorized  ErrorReason = "Unauthorized"
+	ReasonInvalid       ErrorReason = "Invalid"
+	ReasonTimeout       ErrorReason = "Timeout"
+	ReasonExecFailed    ErrorReason = "ExecFailed"
+	ReasonParseFailed   ErrorReason = "ParseFailed"
+)
+
+// Error api 包统一返回的错误, 记录出错的集群/资源, 并保留原始错误
+type Error struct {
+	Reason    ErrorReason `json:"reason"`
+	Cluster   string      `json:"cluster,omitempty"`
+	Namespace string      `json:"namespace,omitempty"`
+	Kind      string      `json:"kind,omitempty"`
+	Name      string      `json:"name,omitempty"`
+	Err       error       `json:"-"`
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString(string(e.Reason))
+	if e.Cluster != "" {
+		b.WriteString(" cluster=" + e.Cluster)
+	}
+	if e.Kind != "" {
+		b.WriteString(" " + e.Kind)
+	}
+	if e.Namespace != "" && e.Name != "" {
+		b.WriteString(" " + e.Namespace + "/" + e.Name)
+	} else if e.Name != "" || e.Namespace != "" {
+		b.WriteString(" " + e.Namespace + e.Name)
+	}
+	if e.Err != nil {
+		b.WriteString(": " + e.Err.Error())
+	}
+	return b.String()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Message 原始错误信息, 用于前端展示
+func (e *Error) Message() string {
+	if e.Err == nil {
+		return string(e.Reason)
+	}
+	if status := apierrors.APIStatus(nil); errors.As(e.Err, &status) {
+		return status.Status().Message
+	}
+	return e.Err.Error()
+}
+
+// NewError 创建指定类型的错误
+func NewError(reason ErrorReason, kind, namespace, name string, err error) *Error {
+	return &Error{
+		Reason:    reason,
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Err:       err,
+	}
+}
+
+// wrapError 将 client-go 返回的错误转换为 *Error, err 为 nil 时返回 nil
+func wrapError(err error, cluster, kind, namespace, name string) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{
+		Reason:    reasonForError(err),
+		Cluster:   cluster,
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Err:       err,
+	}
+}
+
+func reasonForError(err error) ErrorReason {
+	var kubeConfigErr *KubeConfigError
+	switch {
+	case apierrors.IsNotFound(err), meta.IsNoMatchError(err):
+		return ReasonNotFound
+	case apierrors.IsAlreadyExists(err):
+		return ReasonAlreadyExists
+	case apierrors.IsConflict(err):
+		return ReasonConflict
+	case apierrors.IsForbidden(err):
+		return ReasonForbidden
+	case apierrors.IsUnauthorized(err):
+		return ReasonUnauthorized
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return ReasonInvalid
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err),
+		errors.Is(err, context.DeadlineExceeded):
+		return ReasonTimeout
+	case errors.As(err, &kubeConfigErr):
+		if errors.Is(err, ErrKubeConfigParse) {
+			return ReasonParseFailed
+		}
+		return ReasonInvalid
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ReasonTimeout
+	}
+	return ReasonUnknown
+}
+
+// ReasonOf 返回错误类型, 非 *Error 时按 client-go 错误推断
+func ReasonOf(err error) ErrorReason {
+	if err == nil {
+		return ""
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Reason
+	}
+	return reasonForError(err)
+}
+
+func IsNotFound(err error) bool { return ReasonOf(err) == ReasonNotFound }
+
+func IsAlreadyExists(err error) bool { return ReasonOf(err) == ReasonAlreadyExists }
+
+func IsConflict(err error) bool { return ReasonOf(err) == ReasonConflict }
+
+func IsForbidden(err error) bool { return ReasonOf(err) == ReasonForbidden }
+
+func IsUnauthorized(err error) bool { return ReasonOf(err) == ReasonUnauthorized }
+
+func IsInvalid(err error) bool { return ReasonOf(err) == ReasonInvalid }
+
+func IsTimeout(err error) bool { return ReasonOf(err) == ReasonTimeout }
+
+func IsExecFailed(err error) bool { return ReasonOf(err) == ReasonExecFailed }
+
+func IsParseFailed(err error) bool { return ReasonOf(err) == ReasonParseFailed }
+
+// IsRetryable 判断错误是否为临时错误: 429/5xx/超时/连接重置, 重试同一请求可能成功
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if IsTimeout(err) {
+		return true
+	}
+	switch {
+	case apierrors.IsTooManyRequests(err),
+		apierrors.IsInternalError(err),
+		apierrors.IsServiceUnavailable(err),
+		apierrors.IsUnexpectedServerError(err):
+		return true
+	}
+	if status := apierrors.APIStatus(nil); errors.As(err, &status) {
+		return status.Status().Code >= 500
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		strings.Contains(err.Error(), "connection reset by peer") ||
+		strings.Contains(err.Error(), "http2: client connection lost")
+}
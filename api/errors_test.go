@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestErrorReason(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	tests := []struct {
+		name      string
+		input     error
+		expect    ErrorReason
+		retryable bool
+	}{
+		{name: "not found", input: apierrors.NewNotFound(gr, "nginx"), expect: ReasonNotFound},
+		{name: "already exists", input: apierrors.NewAlreadyExists(gr, "nginx"), expect: ReasonAlreadyExists},
+		{name: "conflict", input: apierrors.NewConflict(gr, "nginx", errors.New("modified")), expect: ReasonConflict},
+		{name: "forbidden", input: apierrors.NewForbidden(gr, "nginx", errors.New("rbac")), expect: ReasonForbidden},
+		{name: "unauthorized", input: apierrors.NewUnauthorized("token expired"), expect: ReasonUnauthorized},
+		{name: "invalid", input: apierrors.NewBadRequest("bad"), expect: ReasonInvalid},
+		{name: "server timeout", input: apierrors.NewServerTimeout(gr, "get", 1), expect: ReasonTimeout, retryable: true},
+		{name: "deadline", input: fmt.Errorf("get: %w", context.DeadlineExceeded), expect: ReasonTimeout, retryable: true},
+		{name: "too many requests", input: apierrors.NewTooManyRequests("slow down", 1), expect: ReasonUnknown, retryable: true},
+		{name: "internal error", input: apierrors.NewInternalError(errors.New("etcd")), expect: ReasonUnknown, retryable: true},
+		{name: "kubeconfig", input: &KubeConfigError{Reason: ErrKubeConfigParse, Err: errors.New("yaml")}, expect: ReasonParseFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := wrapError(test.input, "dmz", "Deployment", "cn-online", "nginx")
+			var e *Error
+			assert.True(t, errors.As(err, &e))
+			assert.EqualValues(t, test.expect, e.Reason)
+			assert.EqualValues(t, test.expect, ReasonOf(err))
+			assert.EqualValues(t, test.retryable, IsRetryable(err))
+			assert.ErrorIs(t, err, test.input)
+		})
+	}
+}
+
+func TestClientSetError(t *testing.T) {
+	c := NewFakeClientSet()
+	c.Cluster = "dmz"
+
+	_, err := c.DeploymentGet("cn-online", "nginx")
+	assert.True(t, IsNotFound(err))
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.EqualValues(t, `NotFound cluster=dmz Deployment cn-online/nginx: deployments.apps "nginx" not found`, err.Error())
+
+	_, err = c.ServiceCreateYaml("cn-online", "::bad yaml")
+	assert.True(t, IsParseFailed(err))
+	assert.NoError(t, wrapError(nil, "dmz", "Pod", "", ""))
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// newExecutor 创建 exec 使用的 executor, 测试中替换为 fake
+var newExecutor = remotecommand.NewSPDYExecutor
+
+// ExecRequest 在容器中执行命令的参数
+type ExecRequest struct {
+	Container string   // 为空时使用默认容器, 规则同 PodLogsWithOpts
+	Command   []string // 直接执行, 不经过 shell; 需要管道等 shell 语法时传入 []string{"sh", "-c", "..."}
+	Stdin     io.Reader
+	// Stdout/Stderr 不为空时输出直接写入, 用于大量输出或流式处理, 此时 ExecResult 中对应的字段为空
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// ExecResult 命令执行结果, ExitCode 非 0 不视为错误, 由调用方判断
+type ExecResult struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// PodExecWithRequest 在容器中执行 req.Command, 分别返回 stdout、stderr 和退出码
+func (c *clientSetClient) PodExecWithRequest(namespace, podName string, req ExecRequest) (*ExecResult, error) {
+	return c.PodExecWithRequestContext(c.defaultContext(), namespace, podName, req)
+}
+
+// PodExecWithRequestContext ctx 超时或取消时立即返回 Timeout 错误;
+// client-go 的 Executor 不支持取消, 容器中的命令会继续运行至结束, 之后的输出被丢弃
+func (c *clientSetClient) PodExecWithRequestContext(ctx context.Context, namespace, podName string, req ExecRequest) (*ExecResult, error) {
+	if len(req.Command) == 0 {
+		e := NewError(ReasonInvalid, "Pod", namespace, podName, fmt.Errorf("command must not be empty"))
+		e.Cluster = c.Cluster
+		return nil, e
+	}
+	if req.Container == "" {
+		pod, err := c.PodGetContext(ctx, namespace, podName)
+		if err != nil {
+			return nil, err
+		}
+		req.Container = defaultContainer(pod)
+	}
+	if c.KubeConfig == nil {
+		e := NewError(ReasonInvalid, "Pod", namespace, podName, fmt.Errorf("rest config is required for exec"))
+		e.Cluster = c.Cluster
+		return nil, e
+	}
+
+	exec, err := newExecutor(c.KubeConfig, "POST", c.execURL(namespace, podName, &apiv1.PodExecOptions{
+		Container: req.Container,
+		Command:   req.Command,
+		Stdin:     req.Stdin != nil,
+		Stdout:    true,
+		Stderr:    true,
+	}))
+	if err != nil {
+		return nil, c.wrapError(err, "Pod", namespace, podName)
+	}
+
+	var stdout, stderr bytes.Buffer
+	out := &cancelableWriter{w: req.Stdout}
+	if out.w == nil {
+		out.w = &stdout
+	}
+	errOut := &cancelableWriter{w: req.Stderr}
+	if errOut.w == nil {
+		errOut.w = &stderr
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- exec.Stream(remotecommand.StreamOptions{
+			Stdin:  req.Stdin,
+			Stdout: out,
+			Stderr: errOut,
+		})
+	}()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		out.cancel()
+		errOut.cancel()
+		return nil, c.wrapError(ctx.Err(), "Pod", namespace, podName)
+	}
+
+	result := &ExecResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		result.ExitCode = exitErr.ExitStatus()
+		return result, nil
+	}
+	if err != nil {
+		return nil, c.execError(err, namespace, podName)
+	}
+	return result, nil
+}
+
+// execURL 返回 pods/exec 子资源地址; fake clientset 没有 RESTClient, 此时只生成路径
+func (c *clientSetClient) execURL(namespace, podName string, opts *apiv1.PodExecOptions) *url.URL {
+	var req *rest.Request
+	if client, ok := c.ClientSet.CoreV1().RESTClient().(*rest.RESTClient); ok && client != nil {
+		req = client.Post()
+	} else {
+		req = rest.NewRequestWithClient(&url.URL{}, "/api/v1",
+			rest.ClientContentConfig{GroupVersion: apiv1.SchemeGroupVersion}, nil).Verb("POST")
+	}
+	return req.Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(opts, scheme.ParameterCodec).
+		URL()
+}
+
+// cancelableWriter cancel 之后丢弃写入, 避免提前返回后继续写入调用方的 Writer
+type cancelableWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	canceled bool
+}
+
+func (w *cancelableWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.canceled {
+		return len(p), nil
+	}
+	return w.w.Write(p)
+}
+
+func (w *cancelableWriter) cancel() {
+	w.mu.Lock()
+	w.canceled = true
+	w.mu.Unlock()
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+// fakeExecutor 记录 exec 地址, 通过 stream 模拟容器中的命令
+type fakeExecutor struct {
+	url    *url.URL
+	stream func(remotecommand.StreamOptions) error
+}
+
+func (e *fakeExecutor) Stream(options remotecommand.StreamOptions) error {
+	return e.stream(options)
+}
+
+func useFakeExecutor(t *testing.T, stream func(remotecommand.StreamOptions) error) *fakeExecutor {
+	e := &fakeExecutor{stream: stream}
+	old := newExecutor
+	newExecutor = func(config *rest.Config, method string, url *url.URL) (remotecommand.Executor, error) {
+		e.url = url
+		return e, nil
+	}
+	t.Cleanup(func() { newExecutor = old })
+	return e
+}
+
+func TestPodExecWithRequest(t *testing.T) {
+	c := NewFakeClientSet(&apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default"},
+		Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "api"}, {Name: "sidecar"}}},
+	})
+	e := useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		data, _ := io.ReadAll(o.Stdin)
+		io.WriteString(o.Stdout, strings.ToUpper(string(data)))
+		io.WriteString(o.Stderr, "warning: deprecated\n")
+		return utilexec.CodeExitError{Err: errors.New("command terminated with exit code 3"), Code: 3}
+	})
+
+	result, err := c.PodExecWithRequest("default", "api-0", ExecRequest{
+		Command: []string{"tr", "a-z", "A-Z"},
+		Stdin:   strings.NewReader("hello"),
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ExecResult{Stdout: "HELLO", Stderr: "warning: deprecated\n", ExitCode: 3}, result)
+	assert.Equal(t, "/api/v1/namespaces/default/pods/api-0/exec", e.url.Path)
+	query := e.url.Query()
+	assert.Equal(t, "api", query.Get("container"))
+	assert.EqualValues(t, []string{"tr", "a-z", "A-Z"}, query["command"])
+	assert.Equal(t, "true", query.Get("stdin"))
+
+	var stdout bytes.Buffer
+	result, err = c.PodExecWithRequest("default", "api-0", ExecRequest{
+		Container: "sidecar",
+		Command:   []string{"cat"},
+		Stdin:     strings.NewReader("data"),
+		Stdout:    &stdout,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "DATA", stdout.String())
+	assert.Equal(t, "", result.Stdout)
+	assert.Equal(t, "sidecar", e.url.Query().Get("container"))
+
+	_, err = c.PodExecWithRequest("default", "api-0", ExecRequest{})
+	assert.True(t, IsInvalid(err))
+	_, err = c.PodExecWithRequest("default", "missing", ExecRequest{Command: []string{"ls"}})
+	assert.True(t, IsNotFound(err))
+}
+
+func TestPodExecWithRequestErrors(t *testing.T) {
+	c := NewFakeClientSet()
+	block := make(chan struct{})
+	defer close(block)
+	useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		<-block
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.PodExecWithRequestContext(ctx, "default", "api-0", ExecRequest{Container: "api", Command: []string{"sleep", "60"}})
+	assert.True(t, IsTimeout(err))
+
+	useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		return errors.New("container not running")
+	})
+	_, err = c.PodExecWithRequest("default", "api-0", ExecRequest{Container: "api", Command: []string{"ls"}})
+	assert.True(t, IsExecFailed(err))
+
+	useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		io.WriteString(o.Stdout, " ok \n")
+		return nil
+	})
+	output, err := c.PodExec("default", "api-0", "api", "echo ok")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", output)
+
+	useFakeExecutor(t, func(o remotecommand.StreamOptions) error {
+		io.WriteString(o.Stderr, "sh: foo: not found\n")
+		return utilexec.CodeExitError{Err: errors.New("command terminated with exit code 127"), Code: 127}
+	})
+	_, err = c.PodExec("default", "api-0", "api", "foo")
+	assert.True(t, IsExecFailed(err))
+	assert.Contains(t, err.Error(), "sh: foo: not found")
+}
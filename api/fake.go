@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// FakeResources fake discovery 返回的资源列表, NewFakeDynamicClient 的 render 依赖它做 GVK -> GVR 映射
+var FakeResources = []*metav1.APIResourceList{
+	{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "pods", Namespaced: true, Kind: "Pod"},
+			{Name: "services", Namespaced: true, Kind: "Service"},
+			{Name: "configmaps", Namespaced: true, Kind: "ConfigMap"},
+			{Name: "secrets", Namespaced: true, Kind: "Secret"},
+			{Name: "persistentvolumeclaims", Namespaced: true, Kind: "PersistentVolumeClaim"},
+			{Name: "events", Namespaced: true, Kind: "Event"},
+			{Name: "namespaces", Namespaced: false, Kind: "Namespace"},
+			{Name: "nodes", Namespaced: false, Kind: "Node"},
+		},
+	},
+	{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "deployments", Namespaced: true, Kind: "Deployment"},
+			{Name: "statefulsets", Namespaced: true, Kind: "StatefulSet"},
+			{Name: "daemonsets", Namespaced: true, Kind: "DaemonSet"},
+		},
+	},
+	{
+		GroupVersion: "batch/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "jobs", Namespaced: true, Kind: "Job"},
+			{Name: "cronjobs", Namespaced: true, Kind: "CronJob"},
+		},
+	},
+	{
+		GroupVersion: "networking.k8s.io/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "ingresses", Namespaced: true, Kind: "Ingress"},
+		},
+	},
+	{
+		GroupVersion: "autoscaling/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "horizontalpodautoscalers", Namespaced: true, Kind: "HorizontalPodAutoscaler"},
+		},
+	},
+}
+
+// NewFakeClientSet 基于 client-go fake clientset 初始化 clientSetClient, 用于离线单元测试
+func NewFakeClientSet(objects ...runtime.Object) *clientSetClient {
+	return &clientSetClient{
+		ClientSet:  fake.NewSimpleClientset(objects...),
+		KubeConfig: &rest.Config{},
+	}
+}
+
+// NewFakeDynamicClient 基于 client-go fake dynamic client 初始化 dynamicClient, 用于离线单元测试
+func NewFakeDynamicClient(objects ...runtime.Object) *dynamicClient {
+	discoveryClient := fake.NewSimpleClientset()
+	discoveryClient.Resources = FakeResources
+	return &dynamicClient{
+		DynamicClient:   dynamicfake.NewSimpleDynamicClient(scheme.Scheme, objects...),
+		DiscoveryClient: discoveryClient.Discovery(),
+		KubeConfig:      &rest.Config{},
+	}
+}
+
+// FakeMetricsSource 内存中的 metrics 数据, 赋值给 clientSetClient.Metrics 用于测试用量展示,
+// Err 不为空时所有查询返回该错误, 模拟未安装 metrics-server
+type FakeMetricsSource struct {
+	Pods  []PodMetrics
+	Nodes []NodeMetrics
+	Err   error
+}
+
+func (f *FakeMetricsSource) PodMetrics(ctx context.Context, namespace, name string) (*PodMetrics, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	for i := range f.Pods {
+		if f.Pods[i].Namespace == namespace && f.Pods[i].Name == name {
+			m := f.Pods[i]
+			return &m, nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Group: "metrics.k8s.io", Resource: "pods"}, name)
+}
+
+func (f *FakeMetricsSource) NodeMetricsList(ctx context.Context) ([]NodeMetrics, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	return f.Nodes, nil
+}
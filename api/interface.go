@@ -0,0 +1,320 @@
+package api
+
+import (
+	"context"
+	"io"
+
+	"github.com/gorilla/websocket"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscallingv1 "k8s.io/api/autoscaling/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/events/v1beta1"
+	networkv1 "k8s.io/api/networking/v1"
+	networkbeta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Workloads deployment/statefulSet/daemonSet/job/cronJob/hpa 相关操作
+type Workloads interface {
+	DeploymentCreate(namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error)
+	DeploymentCreateContext(ctx context.Context, namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error)
+	DeploymentList(namespace string, opts ...ListOpts) (*appsv1.DeploymentList, error)
+	DeploymentListContext(ctx context.Context, namespace string, opts ...ListOpts) (*appsv1.DeploymentList, error)
+	DeploymentListFormat(namespace string, opts ...ListOpts) ([]DeploymentInfo, error)
+	DeploymentListFormatContext(ctx context.Context, namespace string, opts ...ListOpts) ([]DeploymentInfo, error)
+	DeploymentGet(namespace string, name string) (*appsv1.Deployment, error)
+	DeploymentGetContext(ctx context.Context, namespace string, name string) (*appsv1.Deployment, error)
+	DeploymentGetFormat(namespace string, name string) (DeploymentInfo, error)
+	DeploymentGetFormatContext(ctx context.Context, namespace string, name string) (DeploymentInfo, error)
+	DeploymentUpdate(namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error)
+	DeploymentUpdateContext(ctx context.Context, namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error)
+	DeploymentDelete(ns string, name string) error
+	DeploymentDeleteContext(ctx context.Context, ns string, name string) error
+	DeploymentDeleteWithOption(ns string, name string, ctx context.Context, opts metav1.DeleteOptions) error
+	DeploymentPods(ns string, name string) (*apiv1.PodList, error)
+	DeploymentPodsContext(ctx context.Context, ns string, name string) (*apiv1.PodList, error)
+	DeploymentPause(namespace, name string) (*appsv1.Deployment, error)
+	DeploymentPauseContext(ctx context.Context, namespace, name string) (*appsv1.Deployment, error)
+	DeploymentResume(namespace, name string) (*appsv1.Deployment, error)
+	DeploymentResumeContext(ctx context.Context, namespace, name string) (*appsv1.Deployment, error)
+	DeploymentUpdateReplicas(namespace, name string, replicas int) (*appsv1.Deployment, error)
+	DeploymentUpdateReplicasContext(ctx context.Context, namespace, name string, replicas int) (*appsv1.Deployment, error)
+	DeploymentUpdateWithRetry(namespace, name string, mutate func(*appsv1.Deployment) error) (*appsv1.Deployment, error)
+	DeploymentUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*appsv1.Deployment) error) (*appsv1.Deployment, error)
+	WatchDeployments(ctx context.Context, namespace string) (<-chan DeploymentWatchEvent, error)
+
+	StatefulSetCreate(namespace string, statefulSet *appsv1.StatefulSet) (*appsv1.StatefulSet, error)
+	StatefulSetCreateContext(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) (*appsv1.StatefulSet, error)
+	StatefulSetList(namespace string, opts ...ListOpts) (*appsv1.StatefulSetList, error)
+	StatefulSetListContext(ctx context.Context, namespace string, opts ...ListOpts) (*appsv1.StatefulSetList, error)
+	StatefulSetListFormat(namespace string, opts ...ListOpts) ([]StatefulSetInfo, error)
+	StatefulSetListFormatContext(ctx context.Context, namespace string, opts ...ListOpts) ([]StatefulSetInfo, error)
+	StatefulSetGet(namespace, name string) (*appsv1.StatefulSet, error)
+	StatefulSetGetContext(ctx context.Context, namespace, name string) (*appsv1.StatefulSet, error)
+	StatefulSetGetFormat(namespace, name string) (StatefulSetInfo, error)
+	StatefulSetGetFormatContext(ctx context.Context, namespace, name string) (StatefulSetInfo, error)
+	StatefulSetUpdate(namespace string, statefulSet *appsv1.StatefulSet) (*appsv1.StatefulSet, error)
+	StatefulSetUpdateContext(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) (*appsv1.StatefulSet, error)
+	StatefulSetDelete(namespace, name string) error
+	StatefulSetDeleteContext(ctx context.Context, namespace, name string) error
+	StatefulSetUpdateReplicas(namespace, name string, replicas int) (*appsv1.StatefulSet, error)
+	StatefulSetUpdateReplicasContext(ctx context.Context, namespace, name string, replicas int) (*appsv1.StatefulSet, error)
+	StatefulSetUpdatePartition(namespace, name string, partition int32) (*appsv1.StatefulSet, error)
+	StatefulSetUpdatePartitionContext(ctx context.Context, namespace, name string, partition int32) (*appsv1.StatefulSet, error)
+	StatefulSetRestart(namespace, name string) (*appsv1.StatefulSet, error)
+	StatefulSetRestartContext(ctx context.Context, namespace, name string) (*appsv1.StatefulSet, error)
+	StatefulSetPods(namespace, name string) ([]apiv1.Pod, error)
+	StatefulSetPodsContext(ctx context.Context, namespace, name string) ([]apiv1.Pod, error)
+	StatefulSetPvcs(namespace, name string) ([]apiv1.PersistentVolumeClaim, error)
+	StatefulSetPvcsContext(ctx context.Context, namespace, name string) ([]apiv1.PersistentVolumeClaim, error)
+	StatefulSetUpdateWithRetry(namespace, name string, mutate func(*appsv1.StatefulSet) error) (*appsv1.StatefulSet, error)
+	StatefulSetUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*appsv1.StatefulSet) error) (*appsv1.StatefulSet, error)
+
+	DaemonSetCreate(namespace string, daemonSet *appsv1.DaemonSet) (*appsv1.DaemonSet, error)
+	DaemonSetCreateContext(ctx context.Context, namespace string, daemonSet *appsv1.DaemonSet) (*appsv1.DaemonSet, error)
+	DaemonSetList(namespace string, opts ...ListOpts) (*appsv1.DaemonSetList, error)
+	DaemonSetListContext(ctx context.Context, namespace string, opts ...ListOpts) (*appsv1.DaemonSetList, error)
+	DaemonSetListFormat(namespace string, opts ...ListOpts) ([]DaemonSetInfo, error)
+	DaemonSetListFormatContext(ctx context.Context, namespace string, opts ...ListOpts) ([]DaemonSetInfo, error)
+	DaemonSetGet(namespace, name string) (*appsv1.DaemonSet, error)
+	DaemonSetGetContext(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error)
+	DaemonSetGetFormat(namespace, name string) (DaemonSetInfo, error)
+	DaemonSetGetFormatContext(ctx context.Context, namespace, name string) (DaemonSetInfo, error)
+	DaemonSetUpdate(namespace string, daemonSet *appsv1.DaemonSet) (*appsv1.DaemonSet, error)
+	DaemonSetUpdateContext(ctx context.Context, namespace string, daemonSet *appsv1.DaemonSet) (*appsv1.DaemonSet, error)
+	DaemonSetDelete(namespace, name string) error
+	DaemonSetDeleteContext(ctx context.Context, namespace, name string) error
+	DaemonSetRestart(namespace, name string) (*appsv1.DaemonSet, error)
+	DaemonSetRestartContext(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error)
+	DaemonSetNodes(namespace, name string) ([]DaemonSetNodePod, error)
+	DaemonSetNodesContext(ctx context.Context, namespace, name string) ([]DaemonSetNodePod, error)
+	DaemonSetUpdateWithRetry(namespace, name string, mutate func(*appsv1.DaemonSet) error) (*appsv1.DaemonSet, error)
+	DaemonSetUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*appsv1.DaemonSet) error) (*appsv1.DaemonSet, error)
+
+	JobCreate(namespace string, job *batchv1.Job) (*batchv1.Job, error)
+	JobCreateContext(ctx context.Context, namespace string, job *batchv1.Job) (*batchv1.Job, error)
+	JobGet(namespace, name string) (*batchv1.Job, error)
+	JobGetContext(ctx context.Context, namespace, name string) (*batchv1.Job, error)
+	JobGetFormat(namespace, name string) (JobInfo, error)
+	JobGetFormatContext(ctx context.Context, namespace, name string) (JobInfo, error)
+	JobList(namespace string, opts ...ListOpts) (*batchv1.JobList, error)
+	JobListContext(ctx context.Context, namespace string, opts ...ListOpts) (*batchv1.JobList, error)
+	JobDelete(namespace, name string) error
+	JobDeleteContext(ctx context.Context, namespace, name string) error
+	JobPods(namespace, name string) (*apiv1.PodList, error)
+	JobPodsContext(ctx context.Context, namespace, name string) (*apiv1.PodList, error)
+	JobLogs(namespace, name string, follow bool) (map[string]io.ReadCloser, error)
+	JobLogsContext(ctx context.Context, namespace, name string, follow bool) (map[string]io.ReadCloser, error)
+
+	CronJobCreate(namespace string, cronJob *batchv1.CronJob) (*batchv1.CronJob, error)
+	CronJobCreateContext(ctx context.Context, namespace string, cronJob *batchv1.CronJob) (*batchv1.CronJob, error)
+	CronJobGet(namespace, name string) (*batchv1.CronJob, error)
+	CronJobGetContext(ctx context.Context, namespace, name string) (*batchv1.CronJob, error)
+	CronJobList(namespace string, opts ...ListOpts) (*batchv1.CronJobList, error)
+	CronJobListContext(ctx context.Context, namespace string, opts ...ListOpts) (*batchv1.CronJobList, error)
+	CronJobUpdate(namespace string, cronJob *batchv1.CronJob) (*batchv1.CronJob, error)
+	CronJobUpdateContext(ctx context.Context, namespace string, cronJob *batchv1.CronJob) (*batchv1.CronJob, error)
+	CronJobDelete(namespace, name string) error
+	CronJobDeleteContext(ctx context.Context, namespace, name string) error
+	CronJobSuspend(namespace, name string) (*batchv1.CronJob, error)
+	CronJobSuspendContext(ctx context.Context, namespace, name string) (*batchv1.CronJob, error)
+	CronJobResume(namespace, name string) (*batchv1.CronJob, error)
+	CronJobResumeContext(ctx context.Context, namespace, name string) (*batchv1.CronJob, error)
+	CronJobTrigger(namespace, name string) (*batchv1.Job, error)
+	CronJobTriggerContext(ctx context.Context, namespace, name string) (*batchv1.Job, error)
+	CronJobHistory(namespace, name string, limit int) ([]JobInfo, error)
+	CronJobHistoryContext(ctx context.Context, namespace, name string, limit int) ([]JobInfo, error)
+
+	HpaGet(namespace, name string) (*autoscallingv1.HorizontalPodAutoscaler, error)
+	HpaGetContext(ctx context.Context, namespace, name string) (*autoscallingv1.HorizontalPodAutoscaler, error)
+}
+
+// Pods pod 查询/日志/exec 相关操作
+type Pods interface {
+	PodEventsGet(namespace string, name string) ([]PodInfo, error)
+	PodEventsGetContext(ctx context.Context, namespace string, name string) ([]PodInfo, error)
+	DiagnosePod(namespace, name string) (*PodDiagnosis, error)
+	DiagnosePodContext(ctx context.Context, namespace, name string) (*PodDiagnosis, error)
+	PodList(ns string, opts ...ListOpts) (*apiv1.PodList, error)
+	PodListContext(ctx context.Context, ns string, opts ...ListOpts) (*apiv1.PodList, error)
+	Pods(ns string, labelSelector map[string]string, opts ...ListOpts) (*apiv1.PodList, error)
+	PodsContext(ctx context.Context, ns string, labelSelector map[string]string, opts ...ListOpts) (*apiv1.PodList, error)
+	PodDelete(ns, podName string) error
+	PodDeleteContext(ctx context.Context, ns, podName string) error
+	PodGet(namespace, podName string) (*apiv1.Pod, error)
+	PodGetContext(ctx context.Context, namespace, podName string) (*apiv1.Pod, error)
+	PodDetail(namespace, podName string) (PodInfo, error)
+	PodDetailContext(ctx context.Context, namespace, podName string) (PodInfo, error)
+	PodYaml(ns string, podName string) (*apiv1.Pod, error)
+	PodYamlContext(ctx context.Context, ns string, podName string) (*apiv1.Pod, error)
+	PodExec(namespace, podName, container, command string) (string, error)
+	PodExecWithRequest(namespace, podName string, req ExecRequest) (*ExecResult, error)
+	PodExecWithRequestContext(ctx context.Context, namespace, podName string, req ExecRequest) (*ExecResult, error)
+	CopyFromPod(namespace, podName, srcPath, destPath string, opts CopyOpts) error
+	CopyFromPodContext(ctx context.Context, namespace, podName, srcPath, destPath string, opts CopyOpts) error
+	CopyToPod(namespace, podName, srcPath, destPath string, opts CopyOpts) error
+	CopyToPodContext(ctx context.Context, namespace, podName, srcPath, destPath string, opts CopyOpts) error
+	PodTTY(namespace, podName, container, shellType string, conn *websocket.Conn, cols, rows uint16) error
+	PodLogs(namespace string, podName, containerName string, follow bool) (io.ReadCloser, error)
+	PodLogsContext(ctx context.Context, namespace string, podName, containerName string, follow bool) (io.ReadCloser, error)
+	PodLogsWithOpts(namespace, podName string, opts PodLogOpts) (io.ReadCloser, error)
+	PodLogsWithOptsContext(ctx context.Context, namespace, podName string, opts PodLogOpts) (io.ReadCloser, error)
+	WorkloadLogs(namespace, kind, name string, opts PodLogOpts) (<-chan LogLine, error)
+	WorkloadLogsContext(ctx context.Context, namespace, kind, name string, opts PodLogOpts) (<-chan LogLine, error)
+	SearchPodLogs(pods []apiv1.Pod, opts LogSearchOpts) ([]LogMatch, error)
+	SearchPodLogsContext(ctx context.Context, pods []apiv1.Pod, opts LogSearchOpts) ([]LogMatch, error)
+	ExportWorkloadLogs(w io.Writer, namespace, kind, name string, opts PodLogOpts) error
+	ExportWorkloadLogsContext(ctx context.Context, w io.Writer, namespace, kind, name string, opts PodLogOpts) error
+	WatchPods(ctx context.Context, namespace string, labelSelector map[string]string) (<-chan PodWatchEvent, error)
+}
+
+// Networking service/ingress 相关操作
+type Networking interface {
+	ServiceCreate(namespace string, service *apiv1.Service) (*apiv1.Service, error)
+	ServiceCreateContext(ctx context.Context, namespace string, service *apiv1.Service) (*apiv1.Service, error)
+	ServiceGet(namespace, name string) (*apiv1.Service, error)
+	ServiceGetContext(ctx context.Context, namespace, name string) (*apiv1.Service, error)
+	ServiceCreateYaml(namespace, serviceYaml string) (*apiv1.Service, error)
+	ServiceCreateYamlContext(ctx context.Context, namespace, serviceYaml string) (*apiv1.Service, error)
+	ServiceUpdate(namespace string, service *apiv1.Service) (*apiv1.Service, error)
+	ServiceUpdateContext(ctx context.Context, namespace string, service *apiv1.Service) (*apiv1.Service, error)
+	ServiceUpdateYaml(namespace, serviceYaml string) (*apiv1.Service, error)
+	ServiceUpdateYamlContext(ctx context.Context, namespace, serviceYaml string) (*apiv1.Service, error)
+	ServiceDelete(namespace, name string) error
+	ServiceDeleteContext(ctx context.Context, namespace, name string) error
+	ServiceUpdateWithRetry(namespace, name string, mutate func(*apiv1.Service) error) (*apiv1.Service, error)
+	ServiceUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*apiv1.Service) error) (*apiv1.Service, error)
+
+	IngressCreate(namespace string, ingress *networkv1.Ingress) (*networkv1.Ingress, error)
+	IngressCreateContext(ctx context.Context, namespace string, ingress *networkv1.Ingress) (*networkv1.Ingress, error)
+	IngressGetByBeta1(namespace, name string) (*networkbeta1.Ingress, error)
+	IngressGetByBeta1Context(ctx context.Context, namespace, name string) (*networkbeta1.Ingress, error)
+	IngressGet(namespace, name string) (*networkv1.Ingress, error)
+	IngressGetContext(ctx context.Context, namespace, name string) (*networkv1.Ingress, error)
+	IngressList(namespace string, opts ...ListOpts) (*networkv1.IngressList, error)
+	IngressListContext(ctx context.Context, namespace string, opts ...ListOpts) (*networkv1.IngressList, error)
+	IngressCreateYaml(namespace, ingressYaml string) (*networkv1.Ingress, error)
+	IngressCreateYamlContext(ctx context.Context, namespace, ingressYaml string) (*networkv1.Ingress, error)
+	IngressUpdate(namespace string, ingress *networkv1.Ingress) (*networkv1.Ingress, error)
+	IngressUpdateContext(ctx context.Context, namespace string, ingress *networkv1.Ingress) (*networkv1.Ingress, error)
+	IngressDelete(namespace, name string) error
+	IngressDeleteContext(ctx context.Context, namespace, name string) error
+	IngressUpdateWithRetry(namespace, name string, mutate func(*networkv1.Ingress) error) (*networkv1.Ingress, error)
+	IngressUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*networkv1.Ingress) error) (*networkv1.Ingress, error)
+}
+
+// Config secret/configmap/pvc 相关操作
+type Config interface {
+	SecretCreate(namespace string, secret *apiv1.Secret) (*apiv1.Secret, error)
+	SecretCreateContext(ctx context.Context, namespace string, secret *apiv1.Secret) (*apiv1.Secret, error)
+	SecretGet(namespace, name string) (*apiv1.Secret, error)
+	SecretGetContext(ctx context.Context, namespace, name string) (*apiv1.Secret, error)
+	SecretUpdate(namespace string, secret *apiv1.Secret) (*apiv1.Secret, error)
+	SecretUpdateContext(ctx context.Context, namespace string, secret *apiv1.Secret) (*apiv1.Secret, error)
+	SecretUpdateWithRetry(namespace, name string, mutate func(*apiv1.Secret) error) (*apiv1.Secret, error)
+	SecretUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*apiv1.Secret) error) (*apiv1.Secret, error)
+	SecretDelete(namespace, name string) error
+	SecretDeleteContext(ctx context.Context, namespace, name string) error
+
+	ConfigmapGet(namespace, name string) (*apiv1.ConfigMap, error)
+	ConfigmapGetContext(ctx context.Context, namespace, name string) (*apiv1.ConfigMap, error)
+	ConfigmapCreate(namespace string, cm *apiv1.ConfigMap) (*apiv1.ConfigMap, error)
+	ConfigmapCreateContext(ctx context.Context, namespace string, cm *apiv1.ConfigMap) (*apiv1.ConfigMap, error)
+	ConfigmapUpdate(namespace string, cm *apiv1.ConfigMap) (*apiv1.ConfigMap, error)
+	ConfigmapUpdateContext(ctx context.Context, namespace string, cm *apiv1.ConfigMap) (*apiv1.ConfigMap, error)
+	ConfigmapUpdateWithRetry(namespace, name string, mutate func(*apiv1.ConfigMap) error) (*apiv1.ConfigMap, error)
+	ConfigmapUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*apiv1.ConfigMap) error) (*apiv1.ConfigMap, error)
+
+	PvcList(namespace string, opts ...ListOpts) (*apiv1.PersistentVolumeClaimList, error)
+	PvcListContext(ctx context.Context, namespace string, opts ...ListOpts) (*apiv1.PersistentVolumeClaimList, error)
+}
+
+// Cluster namespace/node/event 等集群级别操作
+type Cluster interface {
+	NamespaceList(opts ...ListOpts) (*apiv1.NamespaceList, error)
+	NamespaceListContext(ctx context.Context, opts ...ListOpts) (*apiv1.NamespaceList, error)
+	NamespaceWithContentList(ctx context.Context) (*apiv1.NamespaceList, error)
+	NamespaceListWithOption(ctx context.Context, opts metav1.ListOptions) (*apiv1.NamespaceList, error)
+	NamespaceGet(name string) (*apiv1.Namespace, error)
+	NamespaceGetContext(ctx context.Context, name string) (*apiv1.Namespace, error)
+	NamespaceCreate(name string, opts ...NamespaceOption) (*apiv1.Namespace, error)
+	NamespaceCreateContext(ctx context.Context, name string, opts ...NamespaceOption) (*apiv1.Namespace, error)
+	NamespaceLabel(name string, labels map[string]string) (*apiv1.Namespace, error)
+	NamespaceLabelContext(ctx context.Context, name string, labels map[string]string) (*apiv1.Namespace, error)
+	NamespaceListFormat(opts ...ListOpts) ([]Namespace, error)
+	NamespaceListFormatContext(ctx context.Context, opts ...ListOpts) ([]Namespace, error)
+	NamespaceDelete(name string) error
+	NamespaceDeleteContext(ctx context.Context, name string) error
+
+	EventsList(namespace string, opts ...ListOpts) (*v1beta1.EventList, error)
+	EventsListContext(ctx context.Context, namespace string, opts ...ListOpts) (*v1beta1.EventList, error)
+	EventsDetail() ([]Events, error)
+	EventsDetailContext(ctx context.Context) ([]Events, error)
+	WatchEvents(ctx context.Context, namespace, kind, name string) (<-chan EventWatchEvent, error)
+
+	NodeGet(nodeName string) (*apiv1.Node, error)
+	NodeGetContext(ctx context.Context, nodeName string) (*apiv1.Node, error)
+	NodeListFormat(opts ...ListOpts) ([]Node, error)
+	NodeListFormatContext(ctx context.Context, opts ...ListOpts) ([]Node, error)
+	NodeGetFormat(name string) (Node, error)
+	NodeGetFormatContext(ctx context.Context, name string) (Node, error)
+	CapacityReport() (*ClusterCapacity, error)
+	CapacityReportContext(ctx context.Context) (*ClusterCapacity, error)
+	DeploymentFit(namespace, name string, replicas int) (CapacityFit, error)
+	DeploymentFitContext(ctx context.Context, namespace, name string, replicas int) (CapacityFit, error)
+	NodeUpdate(node *apiv1.Node) (*apiv1.Node, error)
+	NodeUpdateContext(ctx context.Context, node *apiv1.Node) (*apiv1.Node, error)
+	NodeUpdateWithRetry(name string, mutate func(*apiv1.Node) error) (*apiv1.Node, error)
+	NodeUpdateWithRetryContext(ctx context.Context, name string, mutate func(*apiv1.Node) error) (*apiv1.Node, error)
+	NodeCordon(name string) (*apiv1.Node, error)
+	NodeCordonContext(ctx context.Context, name string) (*apiv1.Node, error)
+	NodeUncordon(name string) (*apiv1.Node, error)
+	NodeUncordonContext(ctx context.Context, name string) (*apiv1.Node, error)
+	NodeDrain(name string, opts DrainOptions) error
+	NodeDrainContext(ctx context.Context, name string, opts DrainOptions) error
+	NodeTaintAdd(name string, taint apiv1.Taint) (*apiv1.Node, error)
+	NodeTaintAddContext(ctx context.Context, name string, taint apiv1.Taint) (*apiv1.Node, error)
+	NodeTaintRemove(name, key string, effect apiv1.TaintEffect) (*apiv1.Node, error)
+	NodeTaintRemoveContext(ctx context.Context, name, key string, effect apiv1.TaintEffect) (*apiv1.Node, error)
+	NodeLabelSet(name string, labels map[string]string) (*apiv1.Node, error)
+	NodeLabelSetContext(ctx context.Context, name string, labels map[string]string) (*apiv1.Node, error)
+	NodeLabelRemove(name string, keys ...string) (*apiv1.Node, error)
+	NodeLabelRemoveContext(ctx context.Context, name string, keys ...string) (*apiv1.Node, error)
+	WatchNodes(ctx context.Context) (<-chan NodeWatchEvent, error)
+}
+
+// ClientSet clientSetClient 实现的全部操作
+type ClientSet interface {
+	Workloads
+	Pods
+	Networking
+	Config
+	Cluster
+}
+
+// Dynamic 通过 yaml 操作任意资源
+type Dynamic interface {
+	Create(b []byte) (*unstructured.Unstructured, error)
+	CreateContext(ctx context.Context, b []byte) (*unstructured.Unstructured, error)
+	Update(b []byte) (*unstructured.Unstructured, error)
+	UpdateContext(ctx context.Context, b []byte) (*unstructured.Unstructured, error)
+	UpdateWithRetry(apiVersion, kind, namespace, name string, mutate func(*unstructured.Unstructured) error) (*unstructured.Unstructured, error)
+	UpdateWithRetryContext(ctx context.Context, apiVersion, kind, namespace, name string, mutate func(*unstructured.Unstructured) error) (*unstructured.Unstructured, error)
+	DynamicGet(apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error)
+	DynamicGetContext(ctx context.Context, apiVersion, kind, namespace, name string) (*unstructured.Unstructured, error)
+	Get(b []byte, name string) (*unstructured.Unstructured, error)
+	GetContext(ctx context.Context, b []byte, name string) (*unstructured.Unstructured, error)
+	Delete(b []byte, name string) error
+	DeleteContext(ctx context.Context, b []byte, name string) error
+	DynamicPatch(namespace string, name string) (*unstructured.Unstructured, error)
+	DynamicPatchContext(ctx context.Context, namespace string, name string) (*unstructured.Unstructured, error)
+	Watch(ctx context.Context, gvr schema.GroupVersionResource, namespace, labelSelector string) (<-chan WatchEvent, error)
+}
+
+var (
+	_ ClientSet = &clientSetClient{}
+	_ Dynamic   = &dynamicClient{}
+)
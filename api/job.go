@@ -0,0 +1,314 @@
+package api
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/zhengyansheng/common"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	JobRunning   = "Running"
+	JobSucceeded = "Succeeded"
+	JobFailed    = "Failed"
+	JobSuspended = "Suspended"
+)
+
+// JobCreate 创建 job
+func (c *clientSetClient) JobCreate(namespace string, job *batchv1.Job) (*batchv1.Job, error) {
+	return c.JobCreateContext(c.defaultContext(), namespace, job)
+}
+
+func (c *clientSetClient) JobCreateContext(ctx context.Context, namespace string, job *batchv1.Job) (*batchv1.Job, error) {
+	result, err := c.ClientSet.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	return result, c.wrapError(err, "Job", namespace, job.Name)
+}
+
+func (c *clientSetClient) JobGet(namespace, name string) (*batchv1.Job, error) {
+	return c.JobGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) JobGetContext(ctx context.Context, namespace, name string) (*batchv1.Job, error) {
+	job, err := c.ClientSet.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	return job, c.wrapError(err, "Job", namespace, name)
+}
+
+func (c *clientSetClient) JobList(namespace string, opts ...ListOpts) (*batchv1.JobList, error) {
+	return c.JobListContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) JobListContext(ctx context.Context, namespace string, opts ...ListOpts) (*batchv1.JobList, error) {
+	list, err := c.ClientSet.BatchV1().Jobs(namespace).List(ctx, listOpts(opts).ListOptions())
+	return list, c.wrapError(err, "Job", namespace, "")
+}
+
+// JobDelete 删除 job 及其 pod
+func (c *clientSetClient) JobDelete(namespace, name string) error {
+	return c.JobDeleteContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) JobDeleteContext(ctx context.Context, namespace, name string) error {
+	// job 默认的删除策略会保留 pod, 这里与 kubectl delete job 一致删除 pod
+	deletePolicy := metav1.DeletePropagationBackground
+	err := c.ClientSet.BatchV1().Jobs(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	})
+	return c.wrapError(err, "Job", namespace, name)
+}
+
+// JobGetFormat 查询单个 job 格式化数据
+func (c *clientSetClient) JobGetFormat(namespace, name string) (JobInfo, error) {
+	return c.JobGetFormatContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) JobGetFormatContext(ctx context.Context, namespace, name string) (detail JobInfo, err error) {
+	job, err := c.JobGetContext(ctx, namespace, name)
+	if err != nil {
+		return
+	}
+	return jobInfo(job), nil
+}
+
+// JobPods 查询 job 创建的 pod
+func (c *clientSetClient) JobPods(namespace, name string) (*apiv1.PodList, error) {
+	return c.JobPodsContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) JobPodsContext(ctx context.Context, namespace, name string) (*apiv1.PodList, error) {
+	job, err := c.JobGetContext(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return nil, NewError(ReasonInvalid, "Job", namespace, name, err)
+	}
+	return c.PodListContext(ctx, namespace, ListOpts{LabelSelector: selector.String()})
+}
+
+// JobLogs 通过 PodLogs 获取 job 所有 pod 的日志, key 为 pod 名称, 调用方负责关闭
+func (c *clientSetClient) JobLogs(namespace, name string, follow bool) (map[string]io.ReadCloser, error) {
+	return c.JobLogsContext(c.defaultContext(), namespace, name, follow)
+}
+
+func (c *clientSetClient) JobLogsContext(ctx context.Context, namespace, name string, follow bool) (map[string]io.ReadCloser, error) {
+	pods, err := c.JobPodsContext(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	logs := make(map[string]io.ReadCloser, len(pods.Items))
+	for _, pod := range pods.Items {
+		stream, err := c.PodLogsContext(ctx, namespace, pod.Name, defaultContainer(&pod), follow)
+		if err != nil {
+			for _, s := range logs {
+				s.Close()
+			}
+			return nil, err
+		}
+		logs[pod.Name] = stream
+	}
+	return logs, nil
+}
+
+// CronJobCreate 创建 cronJob
+func (c *clientSetClient) CronJobCreate(namespace string, cronJob *batchv1.CronJob) (*batchv1.CronJob, error) {
+	return c.CronJobCreateContext(c.defaultContext(), namespace, cronJob)
+}
+
+func (c *clientSetClient) CronJobCreateContext(ctx context.Context, namespace string, cronJob *batchv1.CronJob) (*batchv1.CronJob, error) {
+	result, err := c.ClientSet.BatchV1().CronJobs(namespace).Create(ctx, cronJob, metav1.CreateOptions{})
+	return result, c.wrapError(err, "CronJob", namespace, cronJob.Name)
+}
+
+func (c *clientSetClient) CronJobGet(namespace, name string) (*batchv1.CronJob, error) {
+	return c.CronJobGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) CronJobGetContext(ctx context.Context, namespace, name string) (*batchv1.CronJob, error) {
+	cronJob, err := c.ClientSet.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	return cronJob, c.wrapError(err, "CronJob", namespace, name)
+}
+
+func (c *clientSetClient) CronJobList(namespace string, opts ...ListOpts) (*batchv1.CronJobList, error) {
+	return c.CronJobListContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) CronJobListContext(ctx context.Context, namespace string, opts ...ListOpts) (*batchv1.CronJobList, error) {
+	list, err := c.ClientSet.BatchV1().CronJobs(namespace).List(ctx, listOpts(opts).ListOptions())
+	return list, c.wrapError(err, "CronJob", namespace, "")
+}
+
+func (c *clientSetClient) CronJobUpdate(namespace string, cronJob *batchv1.CronJob) (*batchv1.CronJob, error) {
+	return c.CronJobUpdateContext(c.defaultContext(), namespace, cronJob)
+}
+
+func (c *clientSetClient) CronJobUpdateContext(ctx context.Context, namespace string, cronJob *batchv1.CronJob) (*batchv1.CronJob, error) {
+	result, err := c.ClientSet.BatchV1().CronJobs(namespace).Update(ctx, cronJob, metav1.UpdateOptions{})
+	return result, c.wrapError(err, "CronJob", namespace, cronJob.Name)
+}
+
+// CronJobDelete 删除 cronJob 及其创建的 job
+func (c *clientSetClient) CronJobDelete(namespace, name string) error {
+	return c.CronJobDeleteContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) CronJobDeleteContext(ctx context.Context, namespace, name string) error {
+	deletePolicy := metav1.DeletePropagationBackground
+	err := c.ClientSet.BatchV1().CronJobs(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	})
+	return c.wrapError(err, "CronJob", namespace, name)
+}
+
+// CronJobSuspend 暂停 cronJob 调度, 已运行的 job 不受影响
+func (c *clientSetClient) CronJobSuspend(namespace, name string) (*batchv1.CronJob, error) {
+	return c.CronJobSuspendContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) CronJobSuspendContext(ctx context.Context, namespace, name string) (*batchv1.CronJob, error) {
+	return c.cronJobPatch(ctx, namespace, name, []byte(`{"spec":{"suspend":true}}`))
+}
+
+// CronJobResume 恢复 cronJob 调度
+func (c *clientSetClient) CronJobResume(namespace, name string) (*batchv1.CronJob, error) {
+	return c.CronJobResumeContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) CronJobResumeContext(ctx context.Context, namespace, name string) (*batchv1.CronJob, error) {
+	return c.cronJobPatch(ctx, namespace, name, []byte(`{"spec":{"suspend":false}}`))
+}
+
+func (c *clientSetClient) cronJobPatch(ctx context.Context, namespace, name string, data []byte) (*batchv1.CronJob, error) {
+	cronJob, err := c.ClientSet.BatchV1().CronJobs(namespace).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+	return cronJob, c.wrapError(err, "CronJob", namespace, name)
+}
+
+// CronJobTrigger 立即运行一次 cronJob, 等同于 kubectl create job --from=cronjob/<name>
+func (c *clientSetClient) CronJobTrigger(namespace, name string) (*batchv1.Job, error) {
+	return c.CronJobTriggerContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) CronJobTriggerContext(ctx context.Context, namespace, name string) (*batchv1.Job, error) {
+	cronJob, err := c.CronJobGetContext(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return c.JobCreateContext(ctx, namespace, jobFromCronJob(cronJob))
+}
+
+// CronJobHistory 返回 cronJob 最近创建的 job, 按创建时间倒序, limit <= 0 时返回全部
+func (c *clientSetClient) CronJobHistory(namespace, name string, limit int) ([]JobInfo, error) {
+	return c.CronJobHistoryContext(c.defaultContext(), namespace, name, limit)
+}
+
+func (c *clientSetClient) CronJobHistoryContext(ctx context.Context, namespace, name string, limit int) ([]JobInfo, error) {
+	cronJob, err := c.CronJobGetContext(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	jobList, err := c.JobListContext(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*batchv1.Job
+	for i := range jobList.Items {
+		job := &jobList.Items[i]
+		if ref := metav1.GetControllerOf(job); ref != nil && ref.UID == cronJob.UID {
+			jobs = append(jobs, job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[j].CreationTimestamp.Before(&jobs[i].CreationTimestamp)
+	})
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	history := make([]JobInfo, 0, len(jobs))
+	for _, job := range jobs {
+		history = append(history, jobInfo(job))
+	}
+	return history, nil
+}
+
+// jobFromCronJob 按 cronJob 的模板生成 job, 与 kubectl 相同设置 instantiate 注解和 ownerReference
+func jobFromCronJob(cronJob *batchv1.CronJob) *batchv1.Job {
+	annotations := map[string]string{"cronjob.kubernetes.io/instantiate": "manual"}
+	for k, v := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+	labels := make(map[string]string, len(cronJob.Spec.JobTemplate.Labels))
+	for k, v := range cronJob.Spec.JobTemplate.Labels {
+		labels[k] = v
+	}
+	// 名称不能超过 63 个字符, 超出时截断 cronJob 名称; 使用随机后缀, 避免同一秒内多次触发时名称冲突
+	base, suffix := cronJob.Name, "-manual-"+utilrand.String(5)
+	if len(base)+len(suffix) > 63 {
+		base = base[:63-len(suffix)]
+	}
+	isController := true
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        base + suffix,
+			Namespace:   cronJob.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: batchv1.SchemeGroupVersion.String(),
+				Kind:       "CronJob",
+				Name:       cronJob.Name,
+				UID:        cronJob.UID,
+				Controller: &isController,
+			}},
+		},
+		Spec: *cronJob.Spec.JobTemplate.Spec.DeepCopy(),
+	}
+}
+
+func jobInfo(job *batchv1.Job) JobInfo {
+	createTime := job.CreationTimestamp.Format(common.SecLocalTimeFormat)
+	info := JobInfo{
+		Name:      job.Name,
+		Age:       common.SubTime(createTime, common.Now()),
+		Active:    job.Status.Active,
+		Succeeded: job.Status.Succeeded,
+		Failed:    job.Status.Failed,
+		Status:    JobRunning,
+	}
+	if job.Spec.Completions != nil {
+		info.Completions = *job.Spec.Completions
+	}
+	if job.Spec.Suspend != nil && *job.Spec.Suspend {
+		info.Status = JobSuspended
+	}
+	end := time.Now()
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != apiv1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			info.Status = JobSucceeded
+		case batchv1.JobFailed:
+			info.Status = JobFailed
+			info.Message = cond.Message
+			// 失败的 job 没有 completionTime
+			end = cond.LastTransitionTime.Time
+		}
+	}
+	if job.Status.StartTime != nil {
+		info.StartTime = job.Status.StartTime.Format(common.SecLocalTimeFormat)
+		if job.Status.CompletionTime != nil {
+			info.CompletionTime = job.Status.CompletionTime.Format(common.SecLocalTimeFormat)
+			end = job.Status.CompletionTime.Time
+		}
+		info.Duration = end.Sub(job.Status.StartTime.Time).Round(time.Second).String()
+	}
+	return info
+}
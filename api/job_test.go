@@ -0,0 +1,99 @@
+package api
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCronJob(t *testing.T) {
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "cn-online", UID: types.UID("cronjob-uid")},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 2 * * *",
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "backup"}},
+			},
+		},
+	}
+	isController := true
+	start := metav1.NewTime(time.Now().Add(-time.Hour))
+	job := func(name string, created time.Time, cond batchv1.JobConditionType) *batchv1.Job {
+		end := metav1.NewTime(start.Add(90 * time.Second))
+		j := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "cn-online",
+				CreationTimestamp: metav1.NewTime(created),
+				OwnerReferences:   []metav1.OwnerReference{{Kind: "CronJob", Name: "backup", UID: cronJob.UID, Controller: &isController}},
+			},
+			Status: batchv1.JobStatus{
+				StartTime:  &start,
+				Conditions: []batchv1.JobCondition{{Type: cond, Status: apiv1.ConditionTrue, LastTransitionTime: end}},
+			},
+		}
+		if cond == batchv1.JobComplete {
+			j.Status.CompletionTime = &end
+		}
+		return j
+	}
+	c := NewFakeClientSet(
+		cronJob,
+		job("backup-1", time.Now().Add(-2*time.Hour), batchv1.JobComplete),
+		job("backup-2", time.Now().Add(-time.Hour), batchv1.JobFailed),
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "cn-online"}},
+	)
+
+	history, err := c.CronJobHistory("cn-online", "backup", 0)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.EqualValues(t, "backup-2", history[0].Name)
+	assert.EqualValues(t, JobFailed, history[0].Status)
+	assert.EqualValues(t, "1m30s", history[0].Duration)
+	assert.EqualValues(t, JobSucceeded, history[1].Status)
+	assert.EqualValues(t, "1m30s", history[1].Duration)
+
+	triggered, err := c.CronJobTrigger("cn-online", "backup")
+	assert.NoError(t, err)
+	assert.Contains(t, triggered.Name, "backup-manual-")
+	assert.EqualValues(t, "manual", triggered.Annotations["cronjob.kubernetes.io/instantiate"])
+	assert.EqualValues(t, "backup", triggered.Labels["app"])
+	// 连续触发不会因名称相同而冲突
+	again, err := c.CronJobTrigger("cn-online", "backup")
+	assert.NoError(t, err)
+	assert.NotEqual(t, triggered.Name, again.Name)
+	history, err = c.CronJobHistory("cn-online", "backup", 3)
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+
+	suspended, err := c.CronJobSuspend("cn-online", "backup")
+	assert.NoError(t, err)
+	assert.True(t, *suspended.Spec.Suspend)
+	resumed, err := c.CronJobResume("cn-online", "backup")
+	assert.NoError(t, err)
+	assert.False(t, *resumed.Spec.Suspend)
+}
+
+func TestJobLogs(t *testing.T) {
+	c := NewFakeClientSet(
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "cn-online"},
+			Spec:       batchv1.JobSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"job-name": "migrate"}}},
+		},
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "migrate-abcde", Namespace: "cn-online", Labels: map[string]string{"job-name": "migrate"}}},
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "cn-online"}},
+	)
+	logs, err := c.JobLogs("cn-online", "migrate", false)
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	b, err := io.ReadAll(logs["migrate-abcde"])
+	assert.NoError(t, err)
+	assert.EqualValues(t, "fake logs", string(b))
+	logs["migrate-abcde"].Close()
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DefaultPageSize PageIterator 未指定 Limit 时每页的数量
+const DefaultPageSize = 500
+
+// ListOpts list 方法的查询条件, 所有 list 方法都接受可选的 ListOpts, 多个时只使用第一个
+type ListOpts struct {
+	LabelSelector string // 如 app=nginx,env!=test
+	FieldSelector string // 如 status.phase=Running, spec.nodeName=node-1
+	// Limit 每页最多返回的数量, 0 表示不分页; 结果的 Continue 不为空时表示还有下一页
+	Limit    int64
+	Continue string // 上一页返回的 continue token
+	// ResourceVersion 为空时从 etcd 读取最新数据; "0" 时允许返回任意版本, 开销最小,
+	// 配置了已同步的 Cache 且查询可缓存时由 informer 缓存响应, 否则由 apiserver 的缓存响应
+	ResourceVersion      string
+	ResourceVersionMatch metav1.ResourceVersionMatch
+}
+
+// ListOptions 转换为 metav1.ListOptions
+func (o ListOpts) ListOptions() metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector:        o.LabelSelector,
+		FieldSelector:        o.FieldSelector,
+		Limit:                o.Limit,
+		Continue:             o.Continue,
+		ResourceVersion:      o.ResourceVersion,
+		ResourceVersionMatch: o.ResourceVersionMatch,
+	}
+}
+
+// cacheable 只有 ResourceVersion 为 "0" 且只有 label selector 的查询可以由 informer 缓存响应,
+// ResourceVersion 为空表示一致性读取, 缓存可能落后于 etcd
+func (o ListOpts) cacheable() bool {
+	return o.ResourceVersion == "0" && o.FieldSelector == "" && o.Limit == 0 && o.Continue == ""
+}
+
+func (o ListOpts) selector() (labels.Selector, error) {
+	return labels.Parse(o.LabelSelector)
+}
+
+func listOpts(opts []ListOpts) ListOpts {
+	if len(opts) == 0 {
+		return ListOpts{}
+	}
+	return opts[0]
+}
+
+// ListFunc 按 ListOpts 查询一页数据, 如 func(ctx context.Context, o ListOpts) (runtime.Object, error) { return c.PodListContext(ctx, ns, o) }
+type ListFunc func(ctx context.Context, opts ListOpts) (runtime.Object, error)
+
+// PageIterator 按页遍历 list 结果, 调用 Next 时才请求下一页
+//
+//	it := NewPageIterator(ctx, ListOpts{Limit: 100}, fetch)
+//	for it.Next() {
+//		pods := it.Page().(*apiv1.PodList)
+//	}
+//	if err := it.Err(); err != nil {}
+//
+// continue token 过期 (410) 时 Err 满足 apierrors.IsResourceExpired, 需要从头重新遍历
+type PageIterator struct {
+	ctx   context.Context
+	opts  ListOpts
+	fetch ListFunc
+	page  runtime.Object
+	err   error
+	done  bool
+}
+
+// NewPageIterator 初始化分页遍历, opts.Limit 为 0 时使用 DefaultPageSize
+func NewPageIterator(ctx context.Context, opts ListOpts, fetch ListFunc) *PageIterator {
+	if opts.Limit == 0 {
+		opts.Limit = DefaultPageSize
+	}
+	return &PageIterator{ctx: ctx, opts: opts, fetch: fetch}
+}
+
+// Next 请求下一页, 没有更多数据或出错时返回 false
+func (it *PageIterator) Next() bool {
+	if it.done || it.err != nil {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+	page, err := it.fetch(it.ctx, it.opts)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	listMeta, err := meta.ListAccessor(page)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page = page
+	it.opts.Continue = listMeta.GetContinue()
+	// 分页时 resourceVersion 由 continue token 决定
+	it.opts.ResourceVersion = ""
+	it.opts.ResourceVersionMatch = ""
+	it.done = it.opts.Continue == ""
+	return true
+}
+
+// Page 当前页的 list 对象, 需要断言为具体类型, 如 *apiv1.PodList
+func (it *PageIterator) Page() runtime.Object {
+	return it.page
+}
+
+// Err 遍历过程中的错误
+func (it *PageIterator) Err() error {
+	return it.err
+}
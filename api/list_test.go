@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestListOpts(t *testing.T) {
+	c := NewFakeClientSet(
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx-0", Namespace: "cn-online", Labels: map[string]string{"app": "nginx", "env": "online"}}},
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx-1", Namespace: "cn-online", Labels: map[string]string{"app": "nginx", "env": "gray"}}},
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "redis-0", Namespace: "cn-online", Labels: map[string]string{"app": "redis"}}},
+	)
+
+	pods, err := c.PodList("cn-online")
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 3)
+
+	pods, err = c.PodList("cn-online", ListOpts{LabelSelector: "app=nginx"})
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 2)
+
+	pods, err = c.Pods("cn-online", map[string]string{"app": "nginx"}, ListOpts{LabelSelector: "env!=gray"})
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 1)
+	assert.EqualValues(t, "nginx-0", pods.Items[0].Name)
+}
+
+func TestPageIterator(t *testing.T) {
+	var requested []ListOpts
+	fetch := func(ctx context.Context, opts ListOpts) (runtime.Object, error) {
+		requested = append(requested, opts)
+		list := &apiv1.PodList{}
+		page := len(requested)
+		for i := 0; i < int(opts.Limit); i++ {
+			list.Items = append(list.Items, apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod-%d-%d", page, i)}})
+		}
+		if page < 3 {
+			list.Continue = fmt.Sprintf("token-%d", page)
+		}
+		return list, nil
+	}
+
+	it := NewPageIterator(context.Background(), ListOpts{Limit: 2, ResourceVersion: "0", LabelSelector: "app=nginx"}, fetch)
+	var names []string
+	for it.Next() {
+		for _, pod := range it.Page().(*apiv1.PodList).Items {
+			names = append(names, pod.Name)
+		}
+	}
+	assert.NoError(t, it.Err())
+	assert.Len(t, names, 6)
+	assert.Len(t, requested, 3)
+	assert.EqualValues(t, "", requested[0].Continue)
+	assert.EqualValues(t, "token-1", requested[1].Continue)
+	assert.EqualValues(t, "", requested[1].ResourceVersion)
+	assert.EqualValues(t, "app=nginx", requested[2].LabelSelector)
+	assert.False(t, it.Next())
+
+	// 未指定 Limit 时使用默认分页大小, 出错后停止遍历
+	it = NewPageIterator(context.Background(), ListOpts{}, func(ctx context.Context, opts ListOpts) (runtime.Object, error) {
+		assert.EqualValues(t, DefaultPageSize, opts.Limit)
+		return nil, fmt.Errorf("boom")
+	})
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Err(), "boom")
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultContainerAnnotation 与 kubectl logs/exec 一致, 未指定容器时优先使用该注解指定的容器
+const defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// PodLogOpts 查询 pod 日志的参数, 零值表示返回默认容器的全部日志
+type PodLogOpts struct {
+	// Container 容器名称, 可以是 init/ephemeral 容器; 为空时使用 default-container 注解指定的容器, 否则使用第一个容器
+	Container string
+	Follow    bool // 对应 kubectl logs -f
+	// TailLines 只返回最后 N 行, 0 表示全部
+	TailLines int64
+	// SinceSeconds/SinceTime 只返回该时间之后的日志, 不能同时指定
+	SinceSeconds int64
+	SinceTime    time.Time
+	Previous     bool  // 返回上一次退出的容器的日志, 用于排查崩溃原因
+	Timestamps   bool  // 每行日志前加上 RFC3339 时间戳
+	LimitBytes   int64 // 最多返回的字节数, 0 表示不限制
+}
+
+// PodLogOptions 转换为 apiv1.PodLogOptions
+func (o PodLogOpts) PodLogOptions() *apiv1.PodLogOptions {
+	opts := &apiv1.PodLogOptions{
+		Container:  o.Container,
+		Follow:     o.Follow,
+		Previous:   o.Previous,
+		Timestamps: o.Timestamps,
+	}
+	if o.TailLines > 0 {
+		opts.TailLines = &o.TailLines
+	}
+	if o.SinceSeconds > 0 {
+		opts.SinceSeconds = &o.SinceSeconds
+	}
+	if !o.SinceTime.IsZero() {
+		opts.SinceTime = &metav1.Time{Time: o.SinceTime}
+	}
+	if o.LimitBytes > 0 {
+		opts.LimitBytes = &o.LimitBytes
+	}
+	return opts
+}
+
+func (o PodLogOpts) validate() error {
+	switch {
+	case o.SinceSeconds > 0 && !o.SinceTime.IsZero():
+		return fmt.Errorf("only one of SinceSeconds or SinceTime may be specified")
+	case o.TailLines < 0, o.SinceSeconds < 0, o.LimitBytes < 0:
+		return fmt.Errorf("TailLines, SinceSeconds and LimitBytes must not be negative")
+	}
+	return nil
+}
+
+// PodLogsWithOpts 按 opts 查询 pod 日志, 调用方负责关闭
+func (c *clientSetClient) PodLogsWithOpts(namespace, podName string, opts PodLogOpts) (io.ReadCloser, error) {
+	return c.PodLogsWithOptsContext(c.defaultContext(), namespace, podName, opts)
+}
+
+// PodLogsWithOptsContext 按 opts 查询 pod 日志, ctx 取消时关闭日志流, 正在阻塞的 Read 会立即返回,
+// 可以直接传入 http.Request.Context(), 客户端断开后 Follow 的日志流随之结束
+func (c *clientSetClient) PodLogsWithOptsContext(ctx context.Context, namespace, podName string, opts PodLogOpts) (io.ReadCloser, error) {
+	if err := opts.validate(); err != nil {
+		e := NewError(ReasonInvalid, "Pod", namespace, podName, err)
+		e.Cluster = c.Cluster
+		return nil, e
+	}
+	if opts.Container == "" {
+		pod, err := c.PodGetContext(ctx, namespace, podName)
+		if err != nil {
+			return nil, err
+		}
+		opts.Container = defaultContainer(pod)
+	}
+	stream, err := c.ClientSet.CoreV1().Pods(namespace).GetLogs(podName, opts.PodLogOptions()).Stream(ctx)
+	if err != nil {
+		return nil, c.wrapError(err, "Pod", namespace, podName)
+	}
+	return newContextReadCloser(ctx, stream), nil
+}
+
+// defaultContainer 与 kubectl 一致: default-container 注解指定的容器, 否则为第一个容器
+func defaultContainer(pod *apiv1.Pod) string {
+	if name := pod.Annotations[defaultContainerAnnotation]; name != "" && podContainerNames(pod)[name] {
+		return name
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+	return ""
+}
+
+// podContainerNames 返回 pod 所有容器名称, 包括 init 和 ephemeral 容器
+func podContainerNames(pod *apiv1.Pod) map[string]bool {
+	names := map[string]bool{}
+	for _, container := range pod.Spec.InitContainers {
+		names[container.Name] = true
+	}
+	for _, container := range pod.Spec.Containers {
+		names[container.Name] = true
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		names[container.Name] = true
+	}
+	return names
+}
+
+// contextReadCloser ctx 取消时关闭底层的流, 使阻塞的 Read 返回
+type contextReadCloser struct {
+	io.ReadCloser
+	once sync.Once
+	done chan struct{}
+}
+
+func newContextReadCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	r := &contextReadCloser{ReadCloser: rc, done: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.Close()
+		case <-r.done:
+		}
+	}()
+	return r
+}
+
+func (r *contextReadCloser) Close() (err error) {
+	r.once.Do(func() {
+		close(r.done)
+		err = r.ReadCloser.Close()
+	})
+	return
+}
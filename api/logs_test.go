@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPodLogsWithOpts(t *testing.T) {
+	c := NewFakeClientSet(&apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "api-0",
+			Namespace:   "default",
+			Annotations: map[string]string{defaultContainerAnnotation: "api"},
+		},
+		Spec: apiv1.PodSpec{
+			InitContainers: []apiv1.Container{{Name: "migrate"}},
+			Containers:     []apiv1.Container{{Name: "istio-proxy"}, {Name: "api"}},
+		},
+	})
+	lastLogOptions := func() *apiv1.PodLogOptions {
+		actions := c.ClientSet.(*fake.Clientset).Actions()
+		return actions[len(actions)-1].(k8stesting.GenericAction).GetValue().(*apiv1.PodLogOptions)
+	}
+
+	since := time.Now().Add(-time.Hour)
+	stream, err := c.PodLogsWithOpts("default", "api-0", PodLogOpts{
+		TailLines:  100,
+		SinceTime:  since,
+		Previous:   true,
+		Timestamps: true,
+		LimitBytes: 1 << 20,
+	})
+	assert.NoError(t, err)
+	data, err := io.ReadAll(stream)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "fake logs", string(data))
+	assert.NoError(t, stream.Close())
+
+	opts := lastLogOptions()
+	assert.EqualValues(t, "api", opts.Container)
+	assert.EqualValues(t, 100, *opts.TailLines)
+	assert.EqualValues(t, 1<<20, *opts.LimitBytes)
+	assert.True(t, opts.SinceTime.Equal(&metav1.Time{Time: since}))
+	assert.Nil(t, opts.SinceSeconds)
+	assert.True(t, opts.Previous)
+	assert.True(t, opts.Timestamps)
+
+	_, err = c.PodLogsWithOpts("default", "api-0", PodLogOpts{Container: "migrate"})
+	assert.NoError(t, err)
+	opts = lastLogOptions()
+	assert.EqualValues(t, "migrate", opts.Container)
+	assert.Nil(t, opts.TailLines)
+
+	_, err = c.PodLogsWithOpts("default", "api-0", PodLogOpts{SinceSeconds: 60, SinceTime: since})
+	assert.True(t, IsInvalid(err))
+	_, err = c.PodLogsWithOpts("default", "missing", PodLogOpts{})
+	assert.True(t, IsNotFound(err))
+}
+
+func TestContextReadCloser(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	stream := newContextReadCloser(ctx, reader)
+
+	done := make(chan error)
+	go func() {
+		_, err := stream.Read(make([]byte, 16))
+		done <- err
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("read not interrupted by context cancel")
+	}
+	assert.NoError(t, stream.Close())
+}
@@ -0,0 +1,298 @@
+package api
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultMaxLogMatches LogSearchOpts 未指定 MaxMatches 时最多返回的匹配数
+	DefaultMaxLogMatches = 1000
+	// DefaultExportLimitBytes 导出日志时未指定 LimitBytes 时每个文件的大小上限
+	DefaultExportLimitBytes = 10 << 20
+)
+
+// logSearchConcurrency 同时读取的日志流数量
+var logSearchConcurrency = 8
+
+// LogSearchOpts 日志搜索参数
+type LogSearchOpts struct {
+	Pattern   string // 正则表达式, 语法同 regexp
+	Context   int    // 匹配行前后各保留的行数, 类似 grep -C
+	Container string // 为空时搜索所有容器, 包括 init 容器
+	// Previous 为 true 时同时搜索重启前容器的日志
+	Previous     bool
+	SinceSeconds int64
+	// MaxMatches 最多返回的匹配数, 0 表示 DefaultMaxLogMatches
+	MaxMatches int
+}
+
+// LogMatch 一条匹配结果
+type LogMatch struct {
+	Pod        string   `json:"pod"`
+	Container  string   `json:"container"`
+	Previous   bool     `json:"previous"`
+	LineNumber int      `json:"line_number"` // 从 1 开始
+	Line       string   `json:"line"`
+	Before     []string `json:"before"`
+	After      []string `json:"after"`
+}
+
+// logSource 一个容器的一份日志
+type logSource struct {
+	pod       *apiv1.Pod
+	container string
+	previous  bool
+}
+
+// podLogSources 返回 pods 中需要读取的日志, 重启过的容器在 previous 为 true 时额外读取上一次的日志
+func podLogSources(pods []apiv1.Pod, container string, previous bool) []logSource {
+	var sources []logSource
+	for i := range pods {
+		pod := &pods[i]
+		statuses := append(append([]apiv1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			if container != "" && status.Name != container {
+				continue
+			}
+			if status.State.Running != nil || status.State.Terminated != nil {
+				sources = append(sources, logSource{pod: pod, container: status.Name})
+			}
+			if previous && status.RestartCount > 0 {
+				sources = append(sources, logSource{pod: pod, container: status.Name, previous: true})
+			}
+		}
+	}
+	return sources
+}
+
+// openLogs 打开日志流; 上一次的容器日志可能已被 kubelet 清理, 此时返回 nil, nil
+func (c *clientSetClient) openLogs(ctx context.Context, s logSource, opts PodLogOpts) (io.ReadCloser, error) {
+	opts.Container = s.container
+	opts.Previous = s.previous
+	opts.Follow = false
+	rc, err := c.PodLogsWithOptsContext(ctx, s.pod.Namespace, s.pod.Name, opts)
+	if err != nil && s.previous && (IsInvalid(err) || IsNotFound(err)) {
+		return nil, nil
+	}
+	return rc, err
+}
+
+// SearchPodLogs 在 pods 的容器日志中搜索正则表达式, pods 可以来自 DeploymentPods/StatefulSetPods 等,
+// 结果按 pods 以及容器的顺序排列, 超过 MaxMatches 时保留排在前面的匹配
+func (c *clientSetClient) SearchPodLogs(pods []apiv1.Pod, opts LogSearchOpts) ([]LogMatch, error) {
+	return c.SearchPodLogsContext(c.defaultContext(), pods, opts)
+}
+
+func (c *clientSetClient) SearchPodLogsContext(ctx context.Context, pods []apiv1.Pod, opts LogSearchOpts) ([]LogMatch, error) {
+	re, err := regexp.Compile(opts.Pattern)
+	if err != nil || opts.Context < 0 {
+		if err == nil {
+			err = fmt.Errorf("context lines must not be negative")
+		}
+		e := NewError(ReasonInvalid, "Pod", "", "", err)
+		e.Cluster = c.Cluster
+		return nil, e
+	}
+	limit := opts.MaxMatches
+	if limit <= 0 {
+		limit = DefaultMaxLogMatches
+	}
+
+	// 每个日志流最多保留 limit 条, 按 pods 以及容器的顺序合并后再截断, 结果与读取的先后无关
+	sources := podLogSources(pods, opts.Container, opts.Previous)
+	results := make([][]LogMatch, len(sources))
+	errs := make([]error, len(sources))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, logSearchConcurrency)
+	for i := range sources {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			rc, err := c.openLogs(ctx, sources[i], PodLogOpts{SinceSeconds: opts.SinceSeconds})
+			if err != nil || rc == nil {
+				errs[i] = err
+				return
+			}
+			defer rc.Close()
+			results[i], err = searchLogs(rc, re, opts.Context, limit)
+			if err != nil {
+				errs[i] = c.wrapError(err, "Pod", sources[i].pod.Namespace, sources[i].pod.Name)
+			}
+			for j := range results[i] {
+				results[i][j].Pod = sources[i].pod.Name
+				results[i][j].Container = sources[i].container
+				results[i][j].Previous = sources[i].previous
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, c.wrapError(err, "Pod", "", "")
+	}
+	var matches []LogMatch
+	for i := range sources {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		matches = append(matches, results[i]...)
+	}
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// searchLogs 逐行匹配, 达到 limit 条且收集完 After 后停止读取
+func searchLogs(r io.Reader, re *regexp.Regexp, contextLines, limit int) ([]LogMatch, error) {
+	var (
+		matches []LogMatch
+		before  []string
+		open    []int // 尚未收集完 After 的匹配下标
+		number  int
+	)
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" || err == nil {
+			number++
+			line = strings.TrimRight(line, "\r\n")
+			remaining := open[:0]
+			for _, i := range open {
+				matches[i].After = append(matches[i].After, line)
+				if len(matches[i].After) < contextLines {
+					remaining = append(remaining, i)
+				}
+			}
+			open = remaining
+			if len(matches) < limit && re.MatchString(line) {
+				matches = append(matches, LogMatch{
+					LineNumber: number,
+					Line:       line,
+					Before:     append([]string(nil), before...),
+				})
+				if contextLines > 0 {
+					open = append(open, len(matches)-1)
+				}
+			}
+			if contextLines > 0 {
+				if len(before) == contextLines {
+					before = before[1:]
+				}
+				before = append(before, line)
+			}
+			if len(matches) >= limit && len(open) == 0 {
+				return matches, nil
+			}
+		}
+		if err == io.EOF {
+			return matches, nil
+		}
+		if err != nil {
+			return matches, err
+		}
+	}
+}
+
+// ExportWorkloadLogs 将 Deployment/StatefulSet/DaemonSet/Job 所有 pod 及容器的日志打包为 tar.gz 写入 w,
+// 文件路径为 <pod>/<container>.log, 重启过的容器额外包含 <pod>/<container>.previous.log.
+// opts.Follow 会被忽略, opts.LimitBytes 为 0 时每个文件最多 DefaultExportLimitBytes
+func (c *clientSetClient) ExportWorkloadLogs(w io.Writer, namespace, kind, name string, opts PodLogOpts) error {
+	return c.ExportWorkloadLogsContext(c.defaultContext(), w, namespace, kind, name, opts)
+}
+
+func (c *clientSetClient) ExportWorkloadLogsContext(ctx context.Context, w io.Writer, namespace, kind, name string, opts PodLogOpts) error {
+	pods, err := c.workloadPods(ctx, namespace, kind, name)
+	if err != nil {
+		return err
+	}
+	if opts.LimitBytes <= 0 {
+		opts.LimitBytes = DefaultExportLimitBytes
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, s := range podLogSources(pods, opts.Container, true) {
+		rc, err := c.openLogs(ctx, s, opts)
+		if err != nil {
+			return err
+		}
+		if rc == nil {
+			continue
+		}
+		// tar 需要预先知道文件大小, 先读入内存, 大小受 LimitBytes 限制
+		var buf bytes.Buffer
+		_, err = buf.ReadFrom(io.LimitReader(rc, opts.LimitBytes))
+		rc.Close()
+		if err != nil {
+			return c.wrapError(err, "Pod", s.pod.Namespace, s.pod.Name)
+		}
+		file := s.container + ".log"
+		if s.previous {
+			file = s.container + ".previous.log"
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Name:    path.Join(s.pod.Name, file),
+			Mode:    0644,
+			Size:    int64(buf.Len()),
+			ModTime: now,
+		})
+		if err == nil {
+			_, err = tw.Write(buf.Bytes())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// workloadPods 返回工作负载当前的 pod, Deployment 使用 DeploymentPods
+func (c *clientSetClient) workloadPods(ctx context.Context, namespace, kind, name string) ([]apiv1.Pod, error) {
+	if strings.EqualFold(kind, "Deployment") {
+		list, err := c.DeploymentPodsContext(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	}
+	selector, ownerUID, err := c.workloadSelector(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	list, err := c.PodListContext(ctx, namespace, ListOpts{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	var pods []apiv1.Pod
+	for _, pod := range list.Items {
+		if ref := metav1.GetControllerOf(&pod); ref != nil && ref.UID == ownerUID {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSearchLogs(t *testing.T) {
+	logs := strings.Join([]string{
+		"starting server",
+		"connected to db",
+		"ERROR timeout calling payment",
+		"retrying",
+		"ERROR timeout calling payment",
+		"giving up",
+	}, "\n")
+	matches, err := searchLogs(strings.NewReader(logs), regexp.MustCompile(`ERROR`), 1, DefaultMaxLogMatches)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []LogMatch{
+		{LineNumber: 3, Line: "ERROR timeout calling payment", Before: []string{"connected to db"}, After: []string{"retrying"}},
+		{LineNumber: 5, Line: "ERROR timeout calling payment", Before: []string{"retrying"}, After: []string{"giving up"}},
+	}, matches)
+
+	// 达到上限后仍收集完最后一条匹配的 After
+	matches, err = searchLogs(strings.NewReader(logs), regexp.MustCompile(`(?i)error`), 2, 1)
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assert.EqualValues(t, []string{"retrying", "ERROR timeout calling payment"}, matches[0].After)
+
+	matches, err = searchLogs(strings.NewReader(logs), regexp.MustCompile(`(?i)error`), 0, 1)
+	assert.NoError(t, err)
+	assert.Len(t, matches, 1)
+	assert.Nil(t, matches[0].Before)
+}
+
+func TestSearchPodLogs(t *testing.T) {
+	labels := map[string]string{"app": "api"}
+	pod := func(name string, restarts int32) apiv1.Pod {
+		return apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+			Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "api"}}},
+			Status: apiv1.PodStatus{
+				InitContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "migrate", State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{}}},
+				},
+				ContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "api", RestartCount: restarts, State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}}},
+				},
+			},
+		}
+	}
+	api0, api1 := pod("api-0", 2), pod("api-1", 0)
+	c := NewFakeClientSet(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+			Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+		},
+		&api0, &api1,
+	)
+
+	matches, err := c.SearchPodLogs([]apiv1.Pod{api0, api1}, LogSearchOpts{Pattern: "fake", Container: "api", Previous: true})
+	assert.NoError(t, err)
+	var found []string
+	for _, m := range matches {
+		found = append(found, m.Pod+"/"+m.Container)
+		if m.Previous {
+			found[len(found)-1] += "/previous"
+		}
+	}
+	assert.EqualValues(t, []string{"api-0/api", "api-0/api/previous", "api-1/api"}, found)
+
+	// 超过 MaxMatches 时总是保留排在前面的匹配
+	for i := 0; i < 5; i++ {
+		matches, err = c.SearchPodLogs([]apiv1.Pod{api1, api0}, LogSearchOpts{Pattern: "logs$", Container: "api", Previous: true, MaxMatches: 2})
+		assert.NoError(t, err)
+		if assert.Len(t, matches, 2) {
+			assert.Equal(t, "api-1", matches[0].Pod)
+			assert.Equal(t, "api-0", matches[1].Pod)
+			assert.False(t, matches[1].Previous)
+		}
+	}
+
+	_, err = c.SearchPodLogs([]apiv1.Pod{api0}, LogSearchOpts{Pattern: "("})
+	assert.True(t, IsInvalid(err))
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.ExportWorkloadLogs(&buf, "default", "Deployment", "api", PodLogOpts{}))
+	gz, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(tr)
+		files[header.Name] = string(data)
+	}
+	assert.EqualValues(t, map[string]string{
+		"api-0/migrate.log":      "fake logs",
+		"api-0/api.log":          "fake logs",
+		"api-0/api.previous.log": "fake logs",
+		"api-1/migrate.log":      "fake logs",
+		"api-1/api.log":          "fake logs",
+	}, files)
+}
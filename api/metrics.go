@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// metricsAPIPath metrics-server 提供的 metrics.k8s.io 聚合 API
+const metricsAPIPath = "/apis/metrics.k8s.io/v1beta1"
+
+// MetricsSource pod/node 实时用量来源, 默认通过 apiserver 访问 metrics.k8s.io,
+// 测试时可替换为 FakeMetricsSource
+type MetricsSource interface {
+	PodMetrics(ctx context.Context, namespace, name string) (*PodMetrics, error)
+	NodeMetricsList(ctx context.Context) ([]NodeMetrics, error)
+}
+
+// PodMetrics 与 metrics.k8s.io/v1beta1 PodMetrics 结构一致
+type PodMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Timestamp         metav1.Time        `json:"timestamp"`
+	Window            metav1.Duration    `json:"window"`
+	Containers        []ContainerMetrics `json:"containers"`
+}
+
+type ContainerMetrics struct {
+	Name  string             `json:"name"`
+	Usage apiv1.ResourceList `json:"usage"`
+}
+
+// NodeMetrics 与 metrics.k8s.io/v1beta1 NodeMetrics 结构一致
+type NodeMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Timestamp         metav1.Time        `json:"timestamp"`
+	Window            metav1.Duration    `json:"window"`
+	Usage             apiv1.ResourceList `json:"usage"`
+}
+
+// metricsUnavailableTTL 未安装 metrics-server 时, 在此期间不再请求 metrics.k8s.io
+const metricsUnavailableTTL = time.Minute
+
+// restMetricsSource 通过 REST 直接访问 metrics.k8s.io, 无需引入 metrics clientset.
+// metrics.k8s.io 不可用时记录错误, metricsUnavailableTTL 内的查询直接返回该错误
+type restMetricsSource struct {
+	client rest.Interface
+
+	mu               sync.Mutex
+	unavailableErr   error
+	unavailableUntil time.Time
+}
+
+// newRESTMetricsSource client 为 nil 时返回 nil, 即不提供用量数据
+func newRESTMetricsSource(client rest.Interface) MetricsSource {
+	if client == nil {
+		return nil
+	}
+	return &restMetricsSource{client: client}
+}
+
+func (s *restMetricsSource) PodMetrics(ctx context.Context, namespace, name string) (*PodMetrics, error) {
+	data, err := s.get(ctx, true, metricsAPIPath, "namespaces", namespace, "pods", name)
+	if err != nil {
+		return nil, err
+	}
+	m := &PodMetrics{}
+	return m, json.Unmarshal(data, m)
+}
+
+func (s *restMetricsSource) NodeMetricsList(ctx context.Context) ([]NodeMetrics, error) {
+	data, err := s.get(ctx, false, metricsAPIPath, "nodes")
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []NodeMetrics `json:"items"`
+	}
+	return list.Items, json.Unmarshal(data, &list)
+}
+
+// get named 为 true 时查询单个对象, 对象不存在 (如 pod 刚启动还没有数据) 不代表 metrics.k8s.io 不可用
+func (s *restMetricsSource) get(ctx context.Context, named bool, paths ...string) ([]byte, error) {
+	s.mu.Lock()
+	if err := s.unavailableErr; err != nil && time.Now().Before(s.unavailableUntil) {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.mu.Unlock()
+
+	// Error 会解析响应中的 Status, Raw 返回的错误不包含 details
+	result := s.client.Get().AbsPath(paths...).Do(ctx)
+	if err := result.Error(); err != nil {
+		if metricsUnavailable(err, named) {
+			s.mu.Lock()
+			s.unavailableErr, s.unavailableUntil = err, time.Now().Add(metricsUnavailableTTL)
+			s.mu.Unlock()
+		}
+		return nil, err
+	}
+	return result.Raw()
+}
+
+// metricsUnavailable APIService 未注册时返回没有 details 的 404, metrics-server 异常时返回 503
+func metricsUnavailable(err error, named bool) bool {
+	if apierrors.IsServiceUnavailable(err) {
+		return true
+	}
+	if !apierrors.IsNotFound(err) {
+		return false
+	}
+	if !named {
+		return true
+	}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		details := status.Status().Details
+		return details == nil || details.Name == ""
+	}
+	return true
+}
+
+// podMetrics 查询 pod 各容器用量, 未安装 metrics-server 或查询失败时返回 nil, 不影响调用方
+func (c *clientSetClient) podMetrics(ctx context.Context, namespace, name string) map[string]*ContainerUsage {
+	if c.Metrics == nil {
+		return nil
+	}
+	m, err := c.Metrics.PodMetrics(ctx, namespace, name)
+	if err != nil {
+		return nil
+	}
+	usage := make(map[string]*ContainerUsage, len(m.Containers))
+	for _, container := range m.Containers {
+		usage[container.Name] = &ContainerUsage{
+			Cpu: container.Usage.Cpu().String(),
+			Mem: container.Usage.Memory().String(),
+		}
+	}
+	return usage
+}
+
+// nodeMetrics 查询所有 node 用量, 未安装 metrics-server 或查询失败时返回 nil, 不影响调用方
+func (c *clientSetClient) nodeMetrics(ctx context.Context) map[string]apiv1.ResourceList {
+	if c.Metrics == nil {
+		return nil
+	}
+	list, err := c.Metrics.NodeMetricsList(ctx)
+	if err != nil {
+		return nil
+	}
+	usage := make(map[string]apiv1.ResourceList, len(list))
+	for _, m := range list {
+		usage[m.Name] = m.Usage
+	}
+	return usage
+}
+
+// nodeUsage 与 kubectl top node 一致, 百分比相对于 allocatable
+func nodeUsage(node *apiv1.Node, usage apiv1.ResourceList) *NodeUsage {
+	if usage == nil {
+		return nil
+	}
+	cpu, mem := usage.Cpu(), usage.Memory()
+	return &NodeUsage{
+		Cpu:        cpu.String(),
+		CpuPercent: quantityPercent(cpu, node.Status.Allocatable.Cpu(), true),
+		Mem:        mem.String(),
+		MemPercent: quantityPercent(mem, node.Status.Allocatable.Memory(), false),
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestMetricsUsage(t *testing.T) {
+	usage := func(cpu, mem string) apiv1.ResourceList {
+		return apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse(cpu), apiv1.ResourceMemory: resource.MustParse(mem)}
+	}
+	objects := []runtime.Object{
+		&apiv1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status:     apiv1.NodeStatus{Allocatable: usage("4", "8Gi")},
+		},
+		&apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+			Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "nginx", Image: "nginx:1.21"}}},
+			Status: apiv1.PodStatus{
+				Phase:             apiv1.PodRunning,
+				StartTime:         &metav1.Time{Time: time.Now()},
+				ContainerStatuses: []apiv1.ContainerStatus{{Name: "nginx"}},
+			},
+		},
+	}
+
+	c := NewFakeClientSet(objects...)
+	c.Metrics = &FakeMetricsSource{
+		Pods: []PodMetrics{{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+			Containers: []ContainerMetrics{{Name: "nginx", Usage: usage("250m", "128Mi")}},
+		}},
+		Nodes: []NodeMetrics{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Usage: usage("1", "2Gi")}},
+	}
+	pod, err := c.PodDetail("default", "nginx")
+	assert.NoError(t, err)
+	assert.EqualValues(t, &ContainerUsage{Cpu: "250m", Mem: "128Mi"}, pod.Containers[0].Usage)
+	nodes, err := c.NodeListFormat()
+	assert.NoError(t, err)
+	assert.EqualValues(t, &NodeUsage{Cpu: "1", CpuPercent: 25, Mem: "2Gi", MemPercent: 25}, nodes[0].Usage)
+
+	// 未安装 metrics-server 时不返回用量, 也不影响其他字段
+	c.Metrics = &FakeMetricsSource{Err: fmt.Errorf("the server could not find the requested resource")}
+	pod, err = c.PodDetail("default", "nginx")
+	assert.NoError(t, err)
+	assert.Nil(t, pod.Containers[0].Usage)
+	nodes, err = c.NodeListFormat()
+	assert.NoError(t, err)
+	assert.Nil(t, nodes[0].Usage)
+}
+
+func TestRESTMetricsSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case metricsAPIPath + "/namespaces/default/pods/nginx":
+			fmt.Fprint(w, `{"metadata":{"name":"nginx","namespace":"default"},"window":"30s",
+				"containers":[{"name":"nginx","usage":{"cpu":"1500000n","memory":"4Mi"}}]}`)
+		case metricsAPIPath + "/nodes":
+			fmt.Fprint(w, `{"items":[{"metadata":{"name":"node-1"},"usage":{"cpu":"500m","memory":"1Gi"}}]}`)
+		default:
+			// metrics-server 返回的单个对象不存在
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404,
+				"details":{"name":"missing","group":"metrics.k8s.io","kind":"pods"}}`)
+		}
+	}))
+	defer server.Close()
+
+	clientSet, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	assert.NoError(t, err)
+	source := newRESTMetricsSource(clientSet.Discovery().RESTClient())
+
+	pod, err := source.PodMetrics(context.Background(), "default", "nginx")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "nginx", pod.Containers[0].Name)
+	assert.EqualValues(t, "4Mi", pod.Containers[0].Usage.Memory().String())
+	nodes, err := source.NodeMetricsList(context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, "500m", nodes[0].Usage.Cpu().String())
+
+	_, err = source.PodMetrics(context.Background(), "default", "missing")
+	assert.True(t, IsNotFound(err))
+	// 单个 pod 没有数据不影响后续查询
+	pod, err = source.PodMetrics(context.Background(), "default", "nginx")
+	assert.NoError(t, err)
+	assert.NotNil(t, pod)
+}
+
+func TestRESTMetricsSourceUnavailable(t *testing.T) {
+	for _, code := range []int{http.StatusNotFound, http.StatusServiceUnavailable} {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			http.Error(w, http.StatusText(code), code)
+		}))
+
+		clientSet, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		assert.NoError(t, err)
+		source := newRESTMetricsSource(clientSet.Discovery().RESTClient()).(*restMetricsSource)
+
+		_, err = source.PodMetrics(context.Background(), "default", "nginx")
+		assert.Error(t, err)
+		_, err = source.NodeMetricsList(context.Background())
+		assert.Error(t, err)
+		_, err = source.PodMetrics(context.Background(), "default", "redis")
+		assert.Error(t, err)
+		assert.EqualValues(t, 1, requests, code)
+
+		// 过期后重新检查
+		source.unavailableUntil = time.Now().Add(-time.Second)
+		_, err = source.NodeMetricsList(context.Background())
+		assert.Error(t, err)
+		assert.EqualValues(t, 2, requests, code)
+		server.Close()
+	}
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zhengyansheng/common"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// DefaultNamespaceDeleteTimeout NamespaceDelete 等待 namespace 删除完成的最长时间
+	DefaultNamespaceDeleteTimeout = 5 * time.Minute
+	// BootstrapResourceName NamespaceCreate 初始化的 ResourceQuota/LimitRange 名称
+	BootstrapResourceName = "default"
+	// namespaceRollbackTimeout 初始化失败后删除 namespace 的超时时间
+	namespaceRollbackTimeout = 30 * time.Second
+)
+
+// namespaceDeletePollInterval 等待 namespace 删除时的查询间隔
+var namespaceDeletePollInterval = 2 * time.Second
+
+// NamespaceOption 创建 namespace 时的可选配置
+type NamespaceOption func(b *namespaceBootstrap)
+
+type namespaceBootstrap struct {
+	labels      map[string]string
+	annotations map[string]string
+	quota       *apiv1.ResourceQuota
+	limitRange  *apiv1.LimitRange
+	secrets     []*apiv1.Secret
+}
+
+// WithNamespaceLabels 设置 namespace 标签
+func WithNamespaceLabels(labels map[string]string) NamespaceOption {
+	return func(b *namespaceBootstrap) {
+		b.labels = labels
+	}
+}
+
+// WithNamespaceAnnotations 设置 namespace 注解
+func WithNamespaceAnnotations(annotations map[string]string) NamespaceOption {
+	return func(b *namespaceBootstrap) {
+		b.annotations = annotations
+	}
+}
+
+// WithResourceQuota 同时创建名为 default 的 ResourceQuota, 如 {"requests.cpu": "10", "limits.memory": "20Gi", "pods": "50"}
+func WithResourceQuota(hard apiv1.ResourceList) NamespaceOption {
+	return func(b *namespaceBootstrap) {
+		b.quota = &apiv1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: BootstrapResourceName},
+			Spec:       apiv1.ResourceQuotaSpec{Hard: hard},
+		}
+	}
+}
+
+// WithLimitRange 同时创建名为 default 的 LimitRange, 用于设置容器默认的 requests/limits
+func WithLimitRange(limits ...apiv1.LimitRangeItem) NamespaceOption {
+	return func(b *namespaceBootstrap) {
+		b.limitRange = &apiv1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: BootstrapResourceName},
+			Spec:       apiv1.LimitRangeSpec{Limits: limits},
+		}
+	}
+}
+
+// WithImagePullSecret 同时创建 kubernetes.io/dockerconfigjson 类型的镜像拉取凭证
+func WithImagePullSecret(name, server, username, password string) NamespaceOption {
+	return func(b *namespaceBootstrap) {
+		auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		config, _ := json.Marshal(map[string]interface{}{
+			"auths": map[string]interface{}{
+				server: map[string]string{
+					"username": username,
+					"password": password,
+					"auth":     auth,
+				},
+			},
+		})
+		b.secrets = append(b.secrets, &apiv1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Type:       apiv1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{apiv1.DockerConfigJsonKey: config},
+		})
+	}
+}
+
+// NamespaceCreate 创建 namespace, 并按 opts 初始化 ResourceQuota/LimitRange/镜像拉取凭证
+// 初始化失败时会删除刚创建的 namespace, 避免留下配置不完整的 namespace
+func (c *clientSetClient) NamespaceCreate(name string, opts ...NamespaceOption) (*apiv1.Namespace, error) {
+	return c.NamespaceCreateContext(c.defaultContext(), name, opts...)
+}
+
+func (c *clientSetClient) NamespaceCreateContext(ctx context.Context, name string, opts ...NamespaceOption) (*apiv1.Namespace, error) {
+	b := &namespaceBootstrap{}
+	for _, opt := range opts {
+		opt(b)
+	}
+	ns, err := c.ClientSet.CoreV1().Namespaces().Create(ctx, &apiv1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      b.labels,
+			Annotations: b.annotations,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, c.wrapError(err, "Namespace", "", name)
+	}
+	if err = c.bootstrapNamespace(ctx, name, b); err != nil {
+		// ctx 可能已经取消, 回滚使用独立且有超时的 ctx
+		rollbackCtx, cancel := context.WithTimeout(context.Background(), namespaceRollbackTimeout)
+		defer cancel()
+		if delErr := c.ClientSet.CoreV1().Namespaces().Delete(rollbackCtx, name, metav1.DeleteOptions{}); delErr != nil {
+			// 保留初始化失败的原因, 同时告知调用方 namespace 仍然存在
+			var e *Error
+			err = c.wrapError(err, "Namespace", "", name)
+			errors.As(err, &e)
+			e.Err = fmt.Errorf("%w; rollback delete namespace %s failed: %v", e.Err, name, delErr)
+		}
+		return nil, err
+	}
+	return ns, nil
+}
+
+func (c *clientSetClient) bootstrapNamespace(ctx context.Context, namespace string, b *namespaceBootstrap) error {
+	if b.quota != nil {
+		_, err := c.ClientSet.CoreV1().ResourceQuotas(namespace).Create(ctx, b.quota, metav1.CreateOptions{})
+		if err != nil {
+			return c.wrapError(err, "ResourceQuota", namespace, b.quota.Name)
+		}
+	}
+	if b.limitRange != nil {
+		_, err := c.ClientSet.CoreV1().LimitRanges(namespace).Create(ctx, b.limitRange, metav1.CreateOptions{})
+		if err != nil {
+			return c.wrapError(err, "LimitRange", namespace, b.limitRange.Name)
+		}
+	}
+	for _, secret := range b.secrets {
+		if _, err := c.SecretCreateContext(ctx, namespace, secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NamespaceLabel 合并更新 namespace 标签, value 为空字符串的 key 会被删除
+func (c *clientSetClient) NamespaceLabel(name string, labels map[string]string) (*apiv1.Namespace, error) {
+	return c.NamespaceLabelContext(c.defaultContext(), name, labels)
+}
+
+func (c *clientSetClient) NamespaceLabelContext(ctx context.Context, name string, labels map[string]string) (*apiv1.Namespace, error) {
+	patchLabels := make(map[string]interface{}, len(labels))
+	for k, v := range labels {
+		if v == "" {
+			patchLabels[k] = nil
+		} else {
+			patchLabels[k] = v
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": patchLabels},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ns, err := c.ClientSet.CoreV1().Namespaces().Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+	return ns, c.wrapError(err, "Namespace", "", name)
+}
+
+// NamespaceListFormat 获取 namespace 格式化后的数据
+func (c *clientSetClient) NamespaceListFormat(opts ...ListOpts) ([]Namespace, error) {
+	return c.NamespaceListFormatContext(c.defaultContext(), opts...)
+}
+
+func (c *clientSetClient) NamespaceListFormatContext(ctx context.Context, opts ...ListOpts) (namespaces []Namespace, err error) {
+	list, err := c.NamespaceListContext(ctx, opts...)
+	if err != nil {
+		return
+	}
+	for _, item := range list.Items {
+		createTime := item.CreationTimestamp.Format(common.SecLocalTimeFormat)
+		namespaces = append(namespaces, Namespace{
+			Name:  item.Name,
+			Phase: string(item.Status.Phase),
+			Age:   common.SubTime(createTime, common.Now()),
+		})
+	}
+	return
+}
+
+// NamespaceDelete 删除 namespace 并等待删除完成, 最多等待 DefaultNamespaceDeleteTimeout
+func (c *clientSetClient) NamespaceDelete(name string) error {
+	ctx, cancel := context.WithTimeout(c.defaultContext(), DefaultNamespaceDeleteTimeout)
+	defer cancel()
+	return c.NamespaceDeleteContext(ctx, name)
+}
+
+// NamespaceDeleteContext 删除 namespace 并等待删除完成, ctx 超时仍未删除时返回 Timeout 错误,
+// 错误中包含 *NamespaceTerminatingError, 说明卡住的 finalizer 和剩余资源
+func (c *clientSetClient) NamespaceDeleteContext(ctx context.Context, name string) error {
+	err := c.ClientSet.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		return c.wrapError(err, "Namespace", "", name)
+	}
+
+	var last *apiv1.Namespace
+	err = wait.PollImmediateUntil(namespaceDeletePollInterval, func() (bool, error) {
+		ns, err := c.ClientSet.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case err == nil:
+			last = ns
+			return false, nil
+		case IsNotFound(c.wrapError(err, "Namespace", "", name)):
+			return true, nil
+		case ctx.Err() != nil:
+			return false, nil
+		default:
+			return false, c.wrapError(err, "Namespace", "", name)
+		}
+	}, ctx.Done())
+	if err == nil {
+		return nil
+	}
+	if err != wait.ErrWaitTimeout || last == nil {
+		return err
+	}
+	e := NewError(ReasonTimeout, "Namespace", "", name, namespaceTerminatingError(last))
+	e.Cluster = c.Cluster
+	return e
+}
+
+// NamespaceTerminatingError namespace 长时间处于 Terminating 状态
+type NamespaceTerminatingError struct {
+	Name       string
+	Finalizers []string // spec.finalizers 及 metadata.finalizers 中尚未移除的 finalizer
+	Conditions []string // NamespaceContentRemaining/NamespaceFinalizersRemaining 等 condition 的说明
+}
+
+func (e *NamespaceTerminatingError) Error() string {
+	msg := fmt.Sprintf("namespace %s still terminating", e.Name)
+	if len(e.Finalizers) > 0 {
+		msg += fmt.Sprintf(", finalizers: [%s]", strings.Join(e.Finalizers, ", "))
+	}
+	if len(e.Conditions) > 0 {
+		msg += fmt.Sprintf(", conditions: %s", strings.Join(e.Conditions, "; "))
+	}
+	return msg
+}
+
+func namespaceTerminatingError(ns *apiv1.Namespace) *NamespaceTerminatingError {
+	e := &NamespaceTerminatingError{Name: ns.Name}
+	for _, f := range ns.Spec.Finalizers {
+		e.Finalizers = append(e.Finalizers, string(f))
+	}
+	e.Finalizers = append(e.Finalizers, ns.Finalizers...)
+	for _, cond := range ns.Status.Conditions {
+		if cond.Status != apiv1.ConditionTrue {
+			continue
+		}
+		e.Conditions = append(e.Conditions, fmt.Sprintf("%s: %s", cond.Type, cond.Message))
+	}
+	return e
+}
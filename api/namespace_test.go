@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNamespaceCreate(t *testing.T) {
+	c := NewFakeClientSet()
+	ns, err := c.NamespaceCreate("cn-online",
+		WithNamespaceLabels(map[string]string{"team": "sre"}),
+		WithResourceQuota(apiv1.ResourceList{apiv1.ResourcePods: resource.MustParse("50")}),
+		WithLimitRange(apiv1.LimitRangeItem{
+			Type:    apiv1.LimitTypeContainer,
+			Default: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("500m")},
+		}),
+		WithImagePullSecret("registry", "ccr.ccs.tencentyun.com", "admin", "secret"),
+	)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "sre", ns.Labels["team"])
+
+	quota, err := c.ClientSet.CoreV1().ResourceQuotas("cn-online").Get(context.TODO(), BootstrapResourceName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "50", quota.Spec.Hard.Pods().String())
+	_, err = c.ClientSet.CoreV1().LimitRanges("cn-online").Get(context.TODO(), BootstrapResourceName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	secret, err := c.SecretGet("cn-online", "registry")
+	assert.NoError(t, err)
+	assert.EqualValues(t, apiv1.SecretTypeDockerConfigJson, secret.Type)
+	assert.Contains(t, string(secret.Data[apiv1.DockerConfigJsonKey]), "ccr.ccs.tencentyun.com")
+
+	ns, err = c.NamespaceLabel("cn-online", map[string]string{"team": "", "env": "online"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{"env": "online"}, ns.Labels)
+
+	namespaces, err := c.NamespaceListFormat()
+	assert.NoError(t, err)
+	assert.Len(t, namespaces, 1)
+	assert.EqualValues(t, "cn-online", namespaces[0].Name)
+
+	// 初始化失败时删除 namespace
+	_, err = c.NamespaceCreate("cn-test", WithImagePullSecret("registry", "a", "b", "c"), WithImagePullSecret("registry", "a", "b", "c"))
+	assert.True(t, IsAlreadyExists(err))
+	_, err = c.NamespaceGet("cn-test")
+	assert.True(t, IsNotFound(err))
+
+	// 回滚失败时同时返回两个错误
+	c.ClientSet.(*fake.Clientset).PrependReactor("delete", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("etcd unavailable")
+	})
+	_, err = c.NamespaceCreate("cn-test", WithImagePullSecret("registry", "a", "b", "c"), WithImagePullSecret("registry", "a", "b", "c"))
+	assert.True(t, IsAlreadyExists(err))
+	assert.Contains(t, err.Error(), "rollback delete namespace cn-test failed: etcd unavailable")
+	_, err = c.NamespaceGet("cn-test")
+	assert.NoError(t, err)
+}
+
+func TestNamespaceDelete(t *testing.T) {
+	interval := namespaceDeletePollInterval
+	namespaceDeletePollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { namespaceDeletePollInterval = interval })
+	c := NewFakeClientSet(
+		&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "cn-online"}},
+		&apiv1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "cn-stuck"},
+			Spec:       apiv1.NamespaceSpec{Finalizers: []apiv1.FinalizerName{apiv1.FinalizerKubernetes}},
+			Status: apiv1.NamespaceStatus{
+				Phase: apiv1.NamespaceTerminating,
+				Conditions: []apiv1.NamespaceCondition{{
+					Type:    apiv1.NamespaceContentRemaining,
+					Status:  apiv1.ConditionTrue,
+					Message: "Some resources are remaining: pods. has 1 resource instances",
+				}},
+			},
+		},
+	)
+	assert.NoError(t, c.NamespaceDelete("cn-online"))
+
+	// 模拟 finalizer 未完成, namespace 一直处于 Terminating
+	c.ClientSet.(*fake.Clientset).PrependReactor("delete", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := c.NamespaceDeleteContext(ctx, "cn-stuck")
+	assert.True(t, IsTimeout(err))
+	var stuck *NamespaceTerminatingError
+	assert.True(t, errors.As(err, &stuck))
+	assert.EqualValues(t, []string{"kubernetes"}, stuck.Finalizers)
+	assert.Contains(t, err.Error(), "NamespaceContentRemaining")
+}
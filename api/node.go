@@ -0,0 +1,561 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhengyansheng/common"
+	apiv1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	DrainSkipped  = "Skipped"  // DaemonSet/mirror pod, 不驱逐
+	DrainEvicting = "Evicting" // 已提交驱逐请求
+	DrainBlocked  = "Blocked"  // 被 PodDisruptionBudget 阻止, 稍后重试
+	DrainEvicted  = "Evicted"  // pod 已删除
+	DrainFailed   = "Failed"
+)
+
+const (
+	// mirrorPodAnnotation static pod 在 apiserver 中对应的 mirror pod 注解
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+	// nodeRoleLabelPrefix node-role.kubernetes.io/<role> 角色标签前缀
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+	// nodeRoleLabel 旧版本使用的 kubernetes.io/role=<role> 角色标签
+	nodeRoleLabel = "kubernetes.io/role"
+)
+
+// drainRetryInterval 被 PodDisruptionBudget 阻止时的重试间隔, 与 kubectl drain 一致
+var drainRetryInterval = 5 * time.Second
+
+// DrainOptions NodeDrain 参数
+type DrainOptions struct {
+	// GracePeriodSeconds pod 优雅退出时间, nil 时使用 pod 自身的 terminationGracePeriodSeconds
+	GracePeriodSeconds *int64
+	// Timeout 等待全部 pod 驱逐完成的最长时间, 0 表示只受 ctx 控制
+	Timeout time.Duration
+	// Force 为 true 时驱逐不受 controller 管理的裸 pod, 否则拒绝 drain
+	Force bool
+	// DeleteEmptyDirData 为 true 时驱逐使用 emptyDir 的 pod (数据会丢失), 否则拒绝 drain
+	DeleteEmptyDirData bool
+	// Progress 驱逐进度回调, 串行调用
+	Progress func(DrainProgress)
+}
+
+// DrainProgress 单个 pod 的驱逐进度
+type DrainProgress struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Phase     string `json:"phase"`
+	Message   string `json:"message"`
+}
+
+func (c *clientSetClient) NodeUpdate(node *apiv1.Node) (*apiv1.Node, error) {
+	return c.NodeUpdateContext(c.defaultContext(), node)
+}
+
+func (c *clientSetClient) NodeUpdateContext(ctx context.Context, node *apiv1.Node) (*apiv1.Node, error) {
+	result, err := c.ClientSet.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+	return result, c.wrapError(err, "Node", "", node.Name)
+}
+
+// NodeUpdateWithRetry 获取最新的 node 并执行 mutate 后更新, 冲突或临时错误时自动重试
+func (c *clientSetClient) NodeUpdateWithRetry(name string, mutate func(*apiv1.Node) error) (*apiv1.Node, error) {
+	return c.NodeUpdateWithRetryContext(c.defaultContext(), name, mutate)
+}
+
+func (c *clientSetClient) NodeUpdateWithRetryContext(ctx context.Context, name string, mutate func(*apiv1.Node) error) (result *apiv1.Node, err error) {
+	err = retryUpdate(ctx, func() error {
+		node, err := c.ClientSet.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.wrapError(err, "Node", "", name)
+		}
+		if err = mutate(node); err != nil {
+			return err
+		}
+		result, err = c.NodeUpdateContext(ctx, node)
+		return err
+	})
+	return
+}
+
+// NodeCordon 禁止调度新的 pod 到 node
+func (c *clientSetClient) NodeCordon(name string) (*apiv1.Node, error) {
+	return c.NodeCordonContext(c.defaultContext(), name)
+}
+
+func (c *clientSetClient) NodeCordonContext(ctx context.Context, name string) (*apiv1.Node, error) {
+	return c.nodePatch(ctx, name, []byte(`{"spec":{"unschedulable":true}}`))
+}
+
+// NodeUncordon 恢复 node 调度
+func (c *clientSetClient) NodeUncordon(name string) (*apiv1.Node, error) {
+	return c.NodeUncordonContext(c.defaultContext(), name)
+}
+
+func (c *clientSetClient) NodeUncordonContext(ctx context.Context, name string) (*apiv1.Node, error) {
+	return c.nodePatch(ctx, name, []byte(`{"spec":{"unschedulable":null}}`))
+}
+
+// NodeLabelSet 合并设置 node 标签
+func (c *clientSetClient) NodeLabelSet(name string, labels map[string]string) (*apiv1.Node, error) {
+	return c.NodeLabelSetContext(c.defaultContext(), name, labels)
+}
+
+func (c *clientSetClient) NodeLabelSetContext(ctx context.Context, name string, labels map[string]string) (*apiv1.Node, error) {
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.nodePatch(ctx, name, data)
+}
+
+// NodeLabelRemove 删除 node 标签, 不存在的 key 忽略
+func (c *clientSetClient) NodeLabelRemove(name string, keys ...string) (*apiv1.Node, error) {
+	return c.NodeLabelRemoveContext(c.defaultContext(), name, keys...)
+}
+
+func (c *clientSetClient) NodeLabelRemoveContext(ctx context.Context, name string, keys ...string) (*apiv1.Node, error) {
+	labels := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		labels[key] = nil
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": labels},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.nodePatch(ctx, name, data)
+}
+
+func (c *clientSetClient) nodePatch(ctx context.Context, name string, data []byte) (*apiv1.Node, error) {
+	node, err := c.ClientSet.CoreV1().Nodes().Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+	return node, c.wrapError(err, "Node", "", name)
+}
+
+// NodeTaintAdd 添加污点, 已存在相同 key 和 effect 的污点时替换其 value
+func (c *clientSetClient) NodeTaintAdd(name string, taint apiv1.Taint) (*apiv1.Node, error) {
+	return c.NodeTaintAddContext(c.defaultContext(), name, taint)
+}
+
+func (c *clientSetClient) NodeTaintAddContext(ctx context.Context, name string, taint apiv1.Taint) (*apiv1.Node, error) {
+	if taint.Key == "" || taint.Effect == "" {
+		return nil, NewError(ReasonInvalid, "Node", "", name, fmt.Errorf("taint key and effect are required"))
+	}
+	return c.NodeUpdateWithRetryContext(ctx, name, func(node *apiv1.Node) error {
+		taints := []apiv1.Taint{taint}
+		for _, t := range node.Spec.Taints {
+			if t.Key != taint.Key || t.Effect != taint.Effect {
+				taints = append(taints, t)
+			}
+		}
+		node.Spec.Taints = taints
+		return nil
+	})
+}
+
+// NodeTaintRemove 删除污点, effect 为空时删除该 key 的全部污点
+func (c *clientSetClient) NodeTaintRemove(name, key string, effect apiv1.TaintEffect) (*apiv1.Node, error) {
+	return c.NodeTaintRemoveContext(c.defaultContext(), name, key, effect)
+}
+
+func (c *clientSetClient) NodeTaintRemoveContext(ctx context.Context, name, key string, effect apiv1.TaintEffect) (*apiv1.Node, error) {
+	return c.NodeUpdateWithRetryContext(ctx, name, func(node *apiv1.Node) error {
+		var taints []apiv1.Taint
+		for _, t := range node.Spec.Taints {
+			if t.Key == key && (effect == "" || t.Effect == effect) {
+				continue
+			}
+			taints = append(taints, t)
+		}
+		node.Spec.Taints = taints
+		return nil
+	})
+}
+
+// NodeDrain cordon node 后通过 Eviction API 驱逐其上的 pod, 等同于 kubectl drain
+// DaemonSet 管理的 pod 和 mirror pod 会被跳过; 被 PodDisruptionBudget 阻止时持续重试直到超时
+func (c *clientSetClient) NodeDrain(name string, opts DrainOptions) error {
+	return c.NodeDrainContext(c.defaultContext(), name, opts)
+}
+
+func (c *clientSetClient) NodeDrainContext(ctx context.Context, name string, opts DrainOptions) error {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	if _, err := c.NodeCordonContext(ctx, name); err != nil {
+		return err
+	}
+	podList, err := c.ClientSet.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", name).String(),
+	})
+	if err != nil {
+		return c.wrapError(err, "Pod", "", "")
+	}
+
+	var (
+		mu       sync.Mutex
+		progress = func(p DrainProgress) {
+			if opts.Progress == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			opts.Progress(p)
+		}
+		pods     []apiv1.Pod
+		rejected []string
+	)
+	for _, pod := range podList.Items {
+		skip, reason := drainFilter(&pod, opts)
+		switch {
+		case skip:
+			progress(DrainProgress{Namespace: pod.Namespace, Pod: pod.Name, Phase: DrainSkipped, Message: reason})
+		case reason != "":
+			rejected = append(rejected, fmt.Sprintf("%s/%s (%s)", pod.Namespace, pod.Name, reason))
+		default:
+			pods = append(pods, pod)
+		}
+	}
+	if len(rejected) > 0 {
+		e := NewError(ReasonInvalid, "Node", "", name, fmt.Errorf("cannot drain pods: %s", strings.Join(rejected, ", ")))
+		e.Cluster = c.Cluster
+		return e
+	}
+
+	useV1 := c.evictionV1Supported()
+	errs := make([]error, len(pods))
+	var wg sync.WaitGroup
+	for i := range pods {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.evictPod(ctx, &pods[i], opts.GracePeriodSeconds, useV1, progress)
+		}(i)
+	}
+	wg.Wait()
+
+	var failed []string
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s/%s: %v", pods[i].Namespace, pods[i].Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		reason := ReasonExecFailed
+		if ctx.Err() != nil {
+			reason = ReasonTimeout
+		}
+		e := NewError(reason, "Node", "", name, fmt.Errorf("drain failed: %s", strings.Join(failed, "; ")))
+		e.Cluster = c.Cluster
+		return e
+	}
+	return nil
+}
+
+// drainFilter 判断 pod 是否跳过, 或者因为数据丢失风险拒绝驱逐 (reason 不为空且 skip 为 false)
+func drainFilter(pod *apiv1.Pod, opts DrainOptions) (skip bool, reason string) {
+	if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+		return true, "mirror pod"
+	}
+	controller := metav1.GetControllerOf(pod)
+	if controller != nil && controller.Kind == "DaemonSet" {
+		return true, "managed by DaemonSet"
+	}
+	if pod.Status.Phase == apiv1.PodSucceeded || pod.Status.Phase == apiv1.PodFailed {
+		return false, ""
+	}
+	if controller == nil && !opts.Force {
+		return false, "not managed by controller, use Force"
+	}
+	if !opts.DeleteEmptyDirData {
+		for _, v := range pod.Spec.Volumes {
+			if v.EmptyDir != nil {
+				return false, "uses emptyDir, use DeleteEmptyDirData"
+			}
+		}
+	}
+	return false, ""
+}
+
+// evictionV1Supported apiserver 是否支持 policy/v1 Eviction (1.22+)
+func (c *clientSetClient) evictionV1Supported() bool {
+	resources, err := c.ClientSet.Discovery().ServerResourcesForGroupVersion("v1")
+	if err != nil {
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == "pods/eviction" && r.Group == policyv1.GroupName && r.Version == "v1" {
+			return true
+		}
+	}
+	return false
+}
+
+// evictPod 驱逐 pod 并等待其删除完成
+func (c *clientSetClient) evictPod(ctx context.Context, pod *apiv1.Pod, gracePeriod *int64, useV1 bool, progress func(DrainProgress)) error {
+	report := func(phase, message string) {
+		progress(DrainProgress{Namespace: pod.Namespace, Pod: pod.Name, Phase: phase, Message: message})
+	}
+	deleteOptions := &metav1.DeleteOptions{GracePeriodSeconds: gracePeriod}
+	for {
+		var err error
+		objMeta := metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace}
+		if useV1 {
+			err = c.ClientSet.CoreV1().Pods(pod.Namespace).EvictV1(ctx, &policyv1.Eviction{ObjectMeta: objMeta, DeleteOptions: deleteOptions})
+		} else {
+			err = c.ClientSet.CoreV1().Pods(pod.Namespace).EvictV1beta1(ctx, &policyv1beta1.Eviction{ObjectMeta: objMeta, DeleteOptions: deleteOptions})
+		}
+		err = c.wrapError(err, "Pod", pod.Namespace, pod.Name)
+		switch {
+		case err == nil:
+			report(DrainEvicting, "")
+			return c.waitPodDeleted(ctx, pod, report)
+		case IsNotFound(err):
+			report(DrainEvicted, "")
+			return nil
+		case apierrors.IsTooManyRequests(err):
+			// 429: PodDisruptionBudget 不允许此时驱逐
+			report(DrainBlocked, err.Error())
+		default:
+			report(DrainFailed, err.Error())
+			return err
+		}
+		timer := time.NewTimer(drainRetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			report(DrainFailed, "timeout waiting for PodDisruptionBudget")
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// waitPodDeleted 等待 pod 删除, 同名 pod 被重建 (UID 变化) 也视为删除完成
+func (c *clientSetClient) waitPodDeleted(ctx context.Context, pod *apiv1.Pod, report func(phase, message string)) error {
+	ticker := time.NewTicker(drainRetryInterval / 5)
+	defer ticker.Stop()
+	for {
+		current, err := c.ClientSet.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		err = c.wrapError(err, "Pod", pod.Namespace, pod.Name)
+		if IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+			report(DrainEvicted, "")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			report(DrainFailed, "timeout waiting for pod deletion")
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// NodeGetFormat 查询单个 node 格式化数据
+func (c *clientSetClient) NodeGetFormat(name string) (Node, error) {
+	return c.NodeGetFormatContext(c.defaultContext(), name)
+}
+
+func (c *clientSetClient) NodeGetFormatContext(ctx context.Context, name string) (detail Node, err error) {
+	node, err := c.NodeGetContext(ctx, name)
+	if err != nil {
+		return
+	}
+	pods, err := c.nodePods(ctx, name)
+	if err != nil {
+		return
+	}
+	detail = nodeInfo(node, pods[name])
+	detail.Usage = nodeUsage(node, c.nodeMetrics(ctx)[name])
+	return detail, nil
+}
+
+// nodePods 查询所有未结束的 pod 并按 node 分组, nodeName 不为空时只查询该 node 上的 pod
+func (c *clientSetClient) nodePods(ctx context.Context, nodeName string) (map[string][]*apiv1.Pod, error) {
+	var opts ListOpts
+	if nodeName != "" {
+		opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+	}
+	list, err := c.PodListContext(ctx, apiv1.NamespaceAll, opts)
+	if err != nil {
+		return nil, err
+	}
+	pods := make(map[string][]*apiv1.Pod)
+	for i := range list.Items {
+		pod := &list.Items[i]
+		if pod.Spec.NodeName == "" || podFinished(pod) {
+			continue
+		}
+		pods[pod.Spec.NodeName] = append(pods[pod.Spec.NodeName], pod)
+	}
+	return pods, nil
+}
+
+func podFinished(pod *apiv1.Pod) bool {
+	return pod.Status.Phase == apiv1.PodSucceeded || pod.Status.Phase == apiv1.PodFailed
+}
+
+func nodeInfo(node *apiv1.Node, pods []*apiv1.Pod) Node {
+	createTime := node.CreationTimestamp.Format(common.SecLocalTimeFormat)
+	info := Node{
+		Name:             node.Name,
+		Status:           nodeStatus(node),
+		Roles:            nodeRoles(node),
+		Age:              common.SubTime(createTime, common.Now()),
+		KernelVersion:    node.Status.NodeInfo.KernelVersion,
+		OsImage:          node.Status.NodeInfo.OSImage,
+		Version:          node.Status.NodeInfo.KubeletVersion,
+		Cpu:              node.Status.Allocatable.Cpu().String(),
+		Mem:              node.Status.Allocatable.Memory().String(),
+		ContainerRuntime: node.Status.NodeInfo.ContainerRuntimeVersion,
+		Labels:           node.Labels,
+	}
+	for _, addr := range node.Status.Addresses {
+		switch {
+		case addr.Type == apiv1.NodeInternalIP && info.InternalIp == "":
+			info.InternalIp = addr.Address
+		case addr.Type == apiv1.NodeExternalIP && info.ExternalIp == "":
+			info.ExternalIp = addr.Address
+		}
+	}
+	for _, taint := range node.Spec.Taints {
+		info.Taints = append(info.Taints, taint.ToString())
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type != apiv1.NodeReady && cond.Status == apiv1.ConditionTrue {
+			info.Pressure = append(info.Pressure, string(cond.Type))
+		}
+	}
+
+	requested := apiv1.ResourceList{}
+	for _, pod := range pods {
+		addResourceList(requested, podRequests(pod))
+	}
+	podCount := resource.NewQuantity(int64(len(pods)), resource.DecimalSI)
+	info.CpuAllocation = nodeAllocation(requested.Cpu(), node.Status.Allocatable.Cpu(), true)
+	info.MemAllocation = nodeAllocation(requested.Memory(), node.Status.Allocatable.Memory(), false)
+	info.PodAllocation = nodeAllocation(podCount, node.Status.Allocatable.Pods(), false)
+	return info
+}
+
+// nodeStatus 与 kubectl get node 的 STATUS 列一致
+func nodeStatus(node *apiv1.Node) string {
+	status := "Unknown"
+	for _, cond := range node.Status.Conditions {
+		if cond.Type != apiv1.NodeReady {
+			continue
+		}
+		if cond.Status == apiv1.ConditionTrue {
+			status = "Ready"
+		} else {
+			status = "NotReady"
+		}
+		break
+	}
+	if node.Spec.Unschedulable {
+		status += ",SchedulingDisabled"
+	}
+	return status
+}
+
+// nodeRoles 从 node-role.kubernetes.io/<role> 和 kubernetes.io/role 标签获取角色, 没有时返回 <none>
+func nodeRoles(node *apiv1.Node) string {
+	roles := map[string]struct{}{}
+	for k, v := range node.Labels {
+		switch {
+		case strings.HasPrefix(k, nodeRoleLabelPrefix):
+			if role := strings.TrimPrefix(k, nodeRoleLabelPrefix); role != "" {
+				roles[role] = struct{}{}
+			}
+		case k == nodeRoleLabel && v != "":
+			roles[v] = struct{}{}
+		}
+	}
+	if len(roles) == 0 {
+		return "<none>"
+	}
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func nodeAllocation(requested, allocatable *resource.Quantity, milli bool) NodeAllocation {
+	return NodeAllocation{
+		Requested:   requested.String(),
+		Allocatable: allocatable.String(),
+		Percent:     quantityPercent(requested, allocatable, milli),
+	}
+}
+
+// quantityPercent 计算 used 占 total 的百分比, cpu 等需要按 milli 精度计算
+func quantityPercent(used, total *resource.Quantity, milli bool) int64 {
+	if milli {
+		if t := total.MilliValue(); t > 0 {
+			return used.MilliValue() * 100 / t
+		}
+		return 0
+	}
+	if t := total.Value(); t > 0 {
+		return used.Value() * 100 / t
+	}
+	return 0
+}
+
+// podRequests 计算 pod 的有效 requests, 与调度器一致:
+// max(所有容器 requests 之和, 任一 init 容器 requests) + pod overhead
+func podRequests(pod *apiv1.Pod) apiv1.ResourceList {
+	return podSpecResources(&pod.Spec, func(r apiv1.ResourceRequirements) apiv1.ResourceList { return r.Requests })
+}
+
+// podLimits 按 podRequests 相同的规则计算 pod 的有效 limits, 未设置 limits 的容器按 0 计算
+func podLimits(pod *apiv1.Pod) apiv1.ResourceList {
+	return podSpecResources(&pod.Spec, func(r apiv1.ResourceRequirements) apiv1.ResourceList { return r.Limits })
+}
+
+func podSpecResources(spec *apiv1.PodSpec, get func(apiv1.ResourceRequirements) apiv1.ResourceList) apiv1.ResourceList {
+	list := apiv1.ResourceList{}
+	for _, container := range spec.Containers {
+		addResourceList(list, get(container.Resources))
+	}
+	for _, container := range spec.InitContainers {
+		for name, quantity := range get(container.Resources) {
+			if value, ok := list[name]; !ok || quantity.Cmp(value) > 0 {
+				list[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	addResourceList(list, spec.Overhead)
+	return list
+}
+
+func addResourceList(list, add apiv1.ResourceList) {
+	for name, quantity := range add {
+		if value, ok := list[name]; ok {
+			value.Add(quantity)
+			list[name] = value
+		} else {
+			list[name] = quantity.DeepCopy()
+		}
+	}
+}
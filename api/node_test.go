@@ -0,0 +1,206 @@
+package api
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNodeTaintAndLabel(t *testing.T) {
+	c := NewFakeClientSet(&apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"zone": "gz-3"}},
+		Spec:       apiv1.NodeSpec{Taints: []apiv1.Taint{{Key: "gpu", Value: "a100", Effect: apiv1.TaintEffectNoSchedule}}},
+	})
+
+	node, err := c.NodeTaintAdd("node-1", apiv1.Taint{Key: "gpu", Value: "v100", Effect: apiv1.TaintEffectNoSchedule})
+	assert.NoError(t, err)
+	assert.Len(t, node.Spec.Taints, 1)
+	assert.EqualValues(t, "v100", node.Spec.Taints[0].Value)
+	node, err = c.NodeTaintAdd("node-1", apiv1.Taint{Key: "gpu", Effect: apiv1.TaintEffectNoExecute})
+	assert.NoError(t, err)
+	assert.Len(t, node.Spec.Taints, 2)
+	node, err = c.NodeTaintRemove("node-1", "gpu", "")
+	assert.NoError(t, err)
+	assert.Len(t, node.Spec.Taints, 0)
+	_, err = c.NodeTaintAdd("node-1", apiv1.Taint{Key: "gpu"})
+	assert.True(t, IsInvalid(err))
+
+	node, err = c.NodeLabelSet("node-1", map[string]string{"role": "db"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{"zone": "gz-3", "role": "db"}, node.Labels)
+	node, err = c.NodeLabelRemove("node-1", "zone")
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]string{"role": "db"}, node.Labels)
+
+	node, err = c.NodeCordon("node-1")
+	assert.NoError(t, err)
+	assert.True(t, node.Spec.Unschedulable)
+	node, err = c.NodeUncordon("node-1")
+	assert.NoError(t, err)
+	assert.False(t, node.Spec.Unschedulable)
+}
+
+func TestNodeDrain(t *testing.T) {
+	interval := drainRetryInterval
+	drainRetryInterval = 10 * time.Millisecond
+	t.Cleanup(func() { drainRetryInterval = interval })
+	isController := true
+	pod := func(name, ownerKind string) *apiv1.Pod {
+		p := &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cn-online"},
+			Spec:       apiv1.PodSpec{NodeName: "node-1"},
+			Status:     apiv1.PodStatus{Phase: apiv1.PodRunning},
+		}
+		if ownerKind != "" {
+			p.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: "owner", Controller: &isController}}
+		}
+		return p
+	}
+	mirror := pod("kube-proxy-node-1", "")
+	mirror.Annotations = map[string]string{mirrorPodAnnotation: "hash"}
+	c := NewFakeClientSet(
+		&apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		pod("nginx-abc", "ReplicaSet"),
+		pod("mysql-0", "StatefulSet"),
+		pod("node-agent-x1", "DaemonSet"),
+		mirror,
+		pod("debug", ""),
+	)
+	fakeClient := c.ClientSet.(*fake.Clientset)
+	// mysql-0 第一次驱逐被 PodDisruptionBudget 阻止
+	var blocked int32
+	fakeClient.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		name := action.(k8stesting.CreateAction).GetObject().(metav1.Object).GetName()
+		if name == "mysql-0" && atomic.AddInt32(&blocked, 1) == 1 {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		return true, nil, fakeClient.Tracker().Delete(apiv1.SchemeGroupVersion.WithResource("pods"), "cn-online", name)
+	})
+
+	// 裸 pod 未指定 Force 时拒绝 drain
+	err := c.NodeDrain("node-1", DrainOptions{Timeout: 5 * time.Second})
+	assert.True(t, IsInvalid(err))
+	assert.Contains(t, err.Error(), "cn-online/debug")
+
+	var progress []DrainProgress
+	err = c.NodeDrain("node-1", DrainOptions{
+		Timeout:  5 * time.Second,
+		Force:    true,
+		Progress: func(p DrainProgress) { progress = append(progress, p) },
+	})
+	assert.NoError(t, err)
+
+	phases := map[string][]string{}
+	for _, p := range progress {
+		phases[p.Pod] = append(phases[p.Pod], p.Phase)
+	}
+	assert.EqualValues(t, []string{DrainSkipped}, phases["node-agent-x1"])
+	assert.EqualValues(t, []string{DrainSkipped}, phases["kube-proxy-node-1"])
+	assert.EqualValues(t, []string{DrainEvicting, DrainEvicted}, phases["nginx-abc"])
+	assert.EqualValues(t, []string{DrainBlocked, DrainEvicting, DrainEvicted}, phases["mysql-0"])
+
+	pods, err := c.PodList("cn-online")
+	assert.NoError(t, err)
+	assert.Len(t, pods.Items, 2)
+	node, err := c.NodeGet("node-1")
+	assert.NoError(t, err)
+	assert.True(t, node.Spec.Unschedulable)
+}
+
+func TestNodeListFormat(t *testing.T) {
+	requests := func(cpu, mem string) apiv1.ResourceRequirements {
+		return apiv1.ResourceRequirements{Requests: apiv1.ResourceList{
+			apiv1.ResourceCPU:    resource.MustParse(cpu),
+			apiv1.ResourceMemory: resource.MustParse(mem),
+		}}
+	}
+	c := NewFakeClientSet(
+		&apiv1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "master-1", Labels: map[string]string{
+				"node-role.kubernetes.io/control-plane": "",
+				"node-role.kubernetes.io/master":        "",
+			}},
+			Spec: apiv1.NodeSpec{
+				Unschedulable: true,
+				Taints:        []apiv1.Taint{{Key: "node-role.kubernetes.io/master", Effect: apiv1.TaintEffectNoSchedule}},
+			},
+			Status: apiv1.NodeStatus{
+				Conditions: []apiv1.NodeCondition{
+					{Type: apiv1.NodeReady, Status: apiv1.ConditionTrue},
+					{Type: apiv1.NodeMemoryPressure, Status: apiv1.ConditionTrue},
+					{Type: apiv1.NodeDiskPressure, Status: apiv1.ConditionFalse},
+				},
+				Addresses: []apiv1.NodeAddress{
+					{Type: apiv1.NodeHostName, Address: "master-1"},
+					{Type: apiv1.NodeInternalIP, Address: "10.0.0.1"},
+					{Type: apiv1.NodeExternalIP, Address: "1.2.3.4"},
+				},
+				Allocatable: apiv1.ResourceList{
+					apiv1.ResourceCPU:    resource.MustParse("4"),
+					apiv1.ResourceMemory: resource.MustParse("8Gi"),
+					apiv1.ResourcePods:   resource.MustParse("110"),
+				},
+			},
+		},
+		&apiv1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: apiv1.NodeStatus{Conditions: []apiv1.NodeCondition{
+				{Type: apiv1.NodeReady, Status: apiv1.ConditionUnknown},
+				{Type: apiv1.NodePIDPressure, Status: apiv1.ConditionFalse},
+			}},
+		},
+		&apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: "kube-system"},
+			Spec: apiv1.PodSpec{
+				NodeName:       "master-1",
+				InitContainers: []apiv1.Container{{Name: "init", Resources: requests("1500m", "1Gi")}},
+				Containers: []apiv1.Container{
+					{Name: "etcd", Resources: requests("500m", "2Gi")},
+					{Name: "sidecar", Resources: requests("500m", "1Gi")},
+				},
+			},
+			Status: apiv1.PodStatus{Phase: apiv1.PodRunning},
+		},
+		&apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+			Spec:       apiv1.PodSpec{NodeName: "master-1", Containers: []apiv1.Container{{Name: "backup", Resources: requests("2", "4Gi")}}},
+			Status:     apiv1.PodStatus{Phase: apiv1.PodSucceeded},
+		},
+	)
+
+	nodes, err := c.NodeListFormat()
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+
+	master := nodes[0]
+	assert.EqualValues(t, "Ready,SchedulingDisabled", master.Status)
+	assert.EqualValues(t, "control-plane,master", master.Roles)
+	assert.EqualValues(t, "10.0.0.1", master.InternalIp)
+	assert.EqualValues(t, "1.2.3.4", master.ExternalIp)
+	assert.EqualValues(t, []string{"node-role.kubernetes.io/master:NoSchedule"}, master.Taints)
+	assert.EqualValues(t, []string{"MemoryPressure"}, master.Pressure)
+	// init 容器 1500m 大于业务容器之和 1000m, 已完成的 pod 不计入
+	assert.EqualValues(t, NodeAllocation{Requested: "1500m", Allocatable: "4", Percent: 37}, master.CpuAllocation)
+	assert.EqualValues(t, NodeAllocation{Requested: "3Gi", Allocatable: "8Gi", Percent: 37}, master.MemAllocation)
+	assert.EqualValues(t, NodeAllocation{Requested: "1", Allocatable: "110", Percent: 0}, master.PodAllocation)
+
+	assert.EqualValues(t, "NotReady", nodes[1].Status)
+	assert.EqualValues(t, "<none>", nodes[1].Roles)
+	assert.Empty(t, nodes[1].Pressure)
+
+	node, err := c.NodeGetFormat("master-1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, master.CpuAllocation, node.CpuAllocation)
+}
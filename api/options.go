@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// Option 调整 client 使用的 rest.Config
+type Option func(cfg *rest.Config) error
+
+// WithQPS 设置 client 每秒请求数, client-go 默认为 5
+func WithQPS(qps float32) Option {
+	return func(cfg *rest.Config) error {
+		cfg.QPS = qps
+		return nil
+	}
+}
+
+// WithBurst 设置 client 突发请求数, client-go 默认为 10
+func WithBurst(burst int) Option {
+	return func(cfg *rest.Config) error {
+		cfg.Burst = burst
+		return nil
+	}
+}
+
+// WithTimeout 设置单个请求的超时时间, 0 表示不超时
+func WithTimeout(timeout time.Duration) Option {
+	return func(cfg *rest.Config) error {
+		cfg.Timeout = timeout
+		return nil
+	}
+}
+
+// WithUserAgent 设置请求的 User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(cfg *rest.Config) error {
+		cfg.UserAgent = userAgent
+		return nil
+	}
+}
+
+// WithImpersonate 以指定用户和用户组的身份访问 apiserver
+func WithImpersonate(user string, groups []string) Option {
+	return func(cfg *rest.Config) error {
+		cfg.Impersonate = rest.ImpersonationConfig{
+			UserName: user,
+			Groups:   groups,
+		}
+		return nil
+	}
+}
+
+// WithProxy 通过 http/https/socks5 代理访问 apiserver
+func WithProxy(proxyURL string) Option {
+	return func(cfg *rest.Config) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url %s: %w", proxyURL, err)
+		}
+		cfg.Proxy = http.ProxyURL(u)
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify 跳过 apiserver 证书校验. This will make your HTTPS connections insecure.
+func WithInsecureSkipVerify() Option {
+	return func(cfg *rest.Config) error {
+		cfg.TLSClientConfig.Insecure = true
+		// client-go 不允许同时设置 Insecure 和 CA
+		cfg.TLSClientConfig.CAData = nil
+		cfg.TLSClientConfig.CAFile = ""
+		return nil
+	}
+}
+
+// WithCAData 使用指定的 CA 证书(PEM)校验 apiserver 证书
+func WithCAData(caData []byte) Option {
+	return func(cfg *rest.Config) error {
+		cfg.TLSClientConfig.Insecure = false
+		cfg.TLSClientConfig.CAData = caData
+		cfg.TLSClientConfig.CAFile = ""
+		return nil
+	}
+}
+
+// applyOptions 在 rest.Config 的副本上应用 options, 不修改传入的 cfg
+func applyOptions(cfg *rest.Config, opts ...Option) (*rest.Config, error) {
+	if len(opts) == 0 {
+		return cfg, nil
+	}
+	config := rest.CopyConfig(cfg)
+	for _, opt := range opts {
+		if err := opt(config); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zhengyansheng/common"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	// defaultRegistry 镜像未指定 registry 时使用 docker hub
+	defaultRegistry = "docker.io"
+	defaultTag      = "latest"
+)
+
+const (
+	ContainerTypeInit      = "init"
+	ContainerTypeRegular   = "container"
+	ContainerTypeEphemeral = "ephemeral"
+)
+
+// ImageRef 解析后的镜像地址, 如 registry.example.com:5000/team/app:v1@sha256:...
+type ImageRef struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"`
+}
+
+// ParseImageRef 按 docker 的规则解析镜像地址, 第一段包含 "." 或 ":" 或为 localhost 时视为 registry,
+// 未指定 registry 时为 docker.io, docker hub 官方镜像补全 library/ 前缀, 未指定 tag 和 digest 时 tag 为 latest
+func ParseImageRef(image string) ImageRef {
+	var ref ImageRef
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	if i := strings.Index(name, "/"); i >= 0 {
+		if first := name[:i]; strings.ContainsAny(first, ".:") || first == "localhost" {
+			ref.Registry, name = first, name[i+1:]
+		}
+	}
+	if ref.Registry == "" {
+		ref.Registry = defaultRegistry
+		if !strings.Contains(name, "/") {
+			name = "library/" + name
+		}
+	}
+	ref.Repository = name
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	return ref
+}
+
+func (r ImageRef) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// podInfo 汇总 pod 状态, usage 为各容器实时用量, 可以为 nil
+func podInfo(pod *apiv1.Pod, usage map[string]*ContainerUsage) PodInfo {
+	p := PodInfo{
+		PodName: pod.Name,
+		PodIP:   pod.Status.PodIP,
+		HostIP:  pod.Status.HostIP,
+		Status:  podDisplayStatus(pod),
+		Message: pod.Status.Message,
+	}
+	if len(pod.Spec.Containers) > 0 {
+		p.Tag = ParseImageRef(pod.Spec.Containers[0].Image).Tag
+	}
+	if pod.Status.StartTime != nil {
+		p.StartTime = pod.Status.StartTime.Format(common.SecLocalTimeFormat)
+		p.Age = common.RuntimeAge(time.Now().Unix() - pod.Status.StartTime.Unix())
+	} else {
+		p.Age = common.RuntimeAge(0)
+	}
+
+	var ready int
+	for _, container := range pod.Spec.InitContainers {
+		p.Containers = append(p.Containers, containerInfo(ContainerTypeInit, container.Name, container.Image,
+			findContainerStatus(pod.Status.InitContainerStatuses, container.Name), usage))
+	}
+	for _, container := range pod.Spec.Containers {
+		info := containerInfo(ContainerTypeRegular, container.Name, container.Image,
+			findContainerStatus(pod.Status.ContainerStatuses, container.Name), usage)
+		if info.Ready {
+			ready++
+		}
+		p.Containers = append(p.Containers, info)
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		p.Containers = append(p.Containers, containerInfo(ContainerTypeEphemeral, container.Name, container.Image,
+			findContainerStatus(pod.Status.EphemeralContainerStatuses, container.Name), usage))
+	}
+	for _, container := range p.Containers {
+		p.RestartCount += container.RestartCount
+	}
+	p.Ready = fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers))
+	return p
+}
+
+func findContainerStatus(statuses []apiv1.ContainerStatus, name string) *apiv1.ContainerStatus {
+	for i := range statuses {
+		if statuses[i].Name == name {
+			return &statuses[i]
+		}
+	}
+	return nil
+}
+
+func containerInfo(containerType, name, image string, status *apiv1.ContainerStatus, usage map[string]*ContainerUsage) Container {
+	c := Container{
+		Name:     name,
+		Type:     containerType,
+		Image:    image,
+		ImageRef: ParseImageRef(image),
+		Usage:    usage[name],
+	}
+	if status == nil {
+		c.State = "Waiting"
+		return c
+	}
+	c.ContainerID = status.ContainerID
+	c.Ready = status.Ready
+	c.RestartCount = status.RestartCount
+	switch state := status.State; {
+	case state.Running != nil:
+		c.State = "Running"
+		c.StartTime = state.Running.StartedAt.Format(common.SecLocalTimeFormat)
+	case state.Terminated != nil:
+		c.State = "Terminated"
+		c.Reason = state.Terminated.Reason
+		c.StartTime = state.Terminated.StartedAt.Format(common.SecLocalTimeFormat)
+	case state.Waiting != nil:
+		c.State = "Waiting"
+		c.Reason = state.Waiting.Reason
+	}
+	if last := status.LastTerminationState.Terminated; last != nil {
+		c.LastTermination = &ContainerTermination{
+			Reason:     last.Reason,
+			ExitCode:   last.ExitCode,
+			Signal:     last.Signal,
+			Message:    last.Message,
+			FinishedAt: last.FinishedAt.Format(common.SecLocalTimeFormat),
+		}
+	}
+	return c
+}
+
+// podDisplayStatus 与 kubectl get pod 的 STATUS 列一致
+func podDisplayStatus(pod *apiv1.Pod) PodStatus {
+	reason := string(pod.Status.Phase)
+	if pod.Status.Reason != "" {
+		reason = pod.Status.Reason
+	}
+
+	initializing := false
+	for i, container := range pod.Status.InitContainerStatuses {
+		switch state := container.State; {
+		case state.Terminated != nil && state.Terminated.ExitCode == 0:
+			continue
+		case state.Terminated != nil:
+			switch {
+			case state.Terminated.Reason != "":
+				reason = "Init:" + state.Terminated.Reason
+			case state.Terminated.Signal != 0:
+				reason = fmt.Sprintf("Init:Signal:%d", state.Terminated.Signal)
+			default:
+				reason = fmt.Sprintf("Init:ExitCode:%d", state.Terminated.ExitCode)
+			}
+		case state.Waiting != nil && state.Waiting.Reason != "" && state.Waiting.Reason != string(PodInitializing):
+			reason = "Init:" + state.Waiting.Reason
+		default:
+			reason = fmt.Sprintf("Init:%d/%d", i, len(pod.Spec.InitContainers))
+		}
+		initializing = true
+		break
+	}
+
+	if !initializing {
+		hasRunning := false
+		for i := len(pod.Status.ContainerStatuses) - 1; i >= 0; i-- {
+			state := pod.Status.ContainerStatuses[i].State
+			switch {
+			case state.Waiting != nil && state.Waiting.Reason != "":
+				reason = state.Waiting.Reason
+			case state.Terminated != nil && state.Terminated.Reason != "":
+				reason = state.Terminated.Reason
+			case state.Terminated != nil && state.Terminated.Signal != 0:
+				reason = fmt.Sprintf("Signal:%d", state.Terminated.Signal)
+			case state.Terminated != nil:
+				reason = fmt.Sprintf("ExitCode:%d", state.Terminated.ExitCode)
+			case pod.Status.ContainerStatuses[i].Ready && state.Running != nil:
+				hasRunning = true
+			}
+		}
+		// 部分容器已正常退出, 其余容器仍在运行
+		if reason == "Completed" && hasRunning {
+			if podReady(pod) {
+				reason = string(apiv1.PodRunning)
+			} else {
+				reason = "NotReady"
+			}
+		}
+	}
+
+	if pod.DeletionTimestamp != nil {
+		if pod.Status.Reason == "NodeLost" {
+			reason = string(apiv1.PodUnknown)
+		} else {
+			reason = string(Terminating)
+		}
+	}
+	return PodStatus(reason)
+}
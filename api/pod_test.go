@@ -0,0 +1,168 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		image  string
+		expect ImageRef
+	}{
+		{"nginx", ImageRef{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"nginx:1.21", ImageRef{Registry: "docker.io", Repository: "library/nginx", Tag: "1.21"}},
+		{"bitnami/redis:7.0", ImageRef{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.0"}},
+		{"registry.example.com:5000/team/app", ImageRef{Registry: "registry.example.com:5000", Repository: "team/app", Tag: "latest"}},
+		{"registry.example.com:5000/team/app:v1.2", ImageRef{Registry: "registry.example.com:5000", Repository: "team/app", Tag: "v1.2"}},
+		{"localhost/app:dev", ImageRef{Registry: "localhost", Repository: "app", Tag: "dev"}},
+		{"gcr.io/distroless/static@sha256:abc123", ImageRef{Registry: "gcr.io", Repository: "distroless/static", Digest: "sha256:abc123"}},
+		{"quay.io/coreos/etcd:v3.5@sha256:abc123", ImageRef{Registry: "quay.io", Repository: "coreos/etcd", Tag: "v3.5", Digest: "sha256:abc123"}},
+	}
+	for _, test := range tests {
+		t.Run(test.image, func(t *testing.T) {
+			assert.EqualValues(t, test.expect, ParseImageRef(test.image))
+		})
+	}
+}
+
+func TestPodDisplayStatus(t *testing.T) {
+	now := metav1.Now()
+	tests := []struct {
+		name   string
+		pod    apiv1.Pod
+		expect PodStatus
+	}{
+		{
+			name:   "pending without containers",
+			pod:    apiv1.Pod{Status: apiv1.PodStatus{Phase: apiv1.PodPending}},
+			expect: "Pending",
+		},
+		{
+			name: "init container running",
+			pod: apiv1.Pod{
+				Spec: apiv1.PodSpec{InitContainers: []apiv1.Container{{Name: "a"}, {Name: "b"}}},
+				Status: apiv1.PodStatus{Phase: apiv1.PodPending, InitContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "a", State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{ExitCode: 0}}},
+					{Name: "b", State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}}},
+				}},
+			},
+			expect: "Init:1/2",
+		},
+		{
+			name: "init container crash",
+			pod: apiv1.Pod{
+				Spec: apiv1.PodSpec{InitContainers: []apiv1.Container{{Name: "a"}}},
+				Status: apiv1.PodStatus{Phase: apiv1.PodPending, InitContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "a", State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				}},
+			},
+			expect: "Init:CrashLoopBackOff",
+		},
+		{
+			name: "container crash",
+			pod: apiv1.Pod{Status: apiv1.PodStatus{Phase: apiv1.PodRunning, ContainerStatuses: []apiv1.ContainerStatus{
+				{Name: "app", State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				{Name: "sidecar", Ready: true, State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}}},
+			}}},
+			expect: CrashLoopBackOff,
+		},
+		{
+			name: "killed by signal",
+			pod: apiv1.Pod{Status: apiv1.PodStatus{Phase: apiv1.PodFailed, ContainerStatuses: []apiv1.ContainerStatus{
+				{Name: "app", State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{Signal: 9, ExitCode: 137}}},
+			}}},
+			expect: "Signal:9",
+		},
+		{
+			name: "completed with running sidecar",
+			pod: apiv1.Pod{Status: apiv1.PodStatus{
+				Phase:      apiv1.PodRunning,
+				Conditions: []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionFalse}},
+				ContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "job", State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{Reason: "Completed"}}},
+					{Name: "sidecar", Ready: true, State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}}},
+				},
+			}},
+			expect: "NotReady",
+		},
+		{
+			name:   "evicted",
+			pod:    apiv1.Pod{Status: apiv1.PodStatus{Phase: apiv1.PodFailed, Reason: "Evicted"}},
+			expect: "Evicted",
+		},
+		{
+			name: "terminating",
+			pod: apiv1.Pod{
+				ObjectMeta: metav1.ObjectMeta{DeletionTimestamp: &now},
+				Status:     apiv1.PodStatus{Phase: apiv1.PodRunning},
+			},
+			expect: Terminating,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.EqualValues(t, test.expect, podDisplayStatus(&test.pod))
+		})
+	}
+}
+
+func TestPodDetail(t *testing.T) {
+	c := NewFakeClientSet(
+		&apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "default"},
+			Spec: apiv1.PodSpec{
+				InitContainers: []apiv1.Container{{Name: "migrate", Image: "registry.example.com:5000/team/migrate"}},
+				Containers: []apiv1.Container{
+					{Name: "api", Image: "registry.example.com:5000/team/api@sha256:abc123"},
+					{Name: "proxy", Image: "envoyproxy/envoy:v1.22"},
+				},
+				EphemeralContainers: []apiv1.EphemeralContainer{{EphemeralContainerCommon: apiv1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"}}},
+			},
+			Status: apiv1.PodStatus{
+				Phase: apiv1.PodRunning,
+				InitContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "migrate", RestartCount: 1, State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{Reason: "Completed"}}},
+				},
+				ContainerStatuses: []apiv1.ContainerStatus{
+					// Ready 但没有 Running 状态的数据不应导致 panic
+					{Name: "api", Ready: true, RestartCount: 3, LastTerminationState: apiv1.ContainerState{
+						Terminated: &apiv1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137},
+					}},
+					{Name: "proxy", RestartCount: 2, State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+				},
+			},
+		},
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: "default"}},
+	)
+
+	pod, err := c.PodDetail("default", "api-0")
+	assert.NoError(t, err)
+	assert.EqualValues(t, CrashLoopBackOff, pod.Status)
+	assert.EqualValues(t, "1/2", pod.Ready)
+	assert.EqualValues(t, 6, pod.RestartCount)
+	assert.EqualValues(t, "", pod.Tag)
+	assert.Len(t, pod.Containers, 4)
+
+	var types, names []string
+	for _, container := range pod.Containers {
+		types = append(types, container.Type)
+		names = append(names, container.Name)
+	}
+	assert.EqualValues(t, []string{ContainerTypeInit, ContainerTypeRegular, ContainerTypeRegular, ContainerTypeEphemeral}, types)
+	assert.EqualValues(t, []string{"migrate", "api", "proxy", "debugger"}, names)
+	assert.EqualValues(t, "sha256:abc123", pod.Containers[1].ImageRef.Digest)
+	assert.EqualValues(t, &ContainerTermination{Reason: "OOMKilled", ExitCode: 137, FinishedAt: pod.Containers[1].LastTermination.FinishedAt},
+		pod.Containers[1].LastTermination)
+	assert.EqualValues(t, "v1.22", pod.Containers[2].ImageRef.Tag)
+	assert.EqualValues(t, "CrashLoopBackOff", pod.Containers[2].Reason)
+	assert.EqualValues(t, "Waiting", pod.Containers[3].State)
+
+	pod, err = c.PodDetail("default", "empty")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "0/0", pod.Ready)
+	assert.Empty(t, pod.Containers)
+}
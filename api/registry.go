@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// ClusterRegistry 多集群 client 注册中心, 按集群名称缓存已构建的 client, 并发安全
+type ClusterRegistry struct {
+	mu       sync.RWMutex
+	clusters map[string]*cluster
+}
+
+type cluster struct {
+	name      string
+	config    *rest.Config
+	clientSet *kubernetes.Clientset
+	dynamic   dynamic.Interface
+	discovery *discovery.DiscoveryClient
+	metrics   MetricsSource     // 同一集群共用, 以便记录 metrics.k8s.io 是否可用
+	caches    map[string]*Cache // 按 namespace 范围缓存, 空字符串表示全部 namespace
+}
+
+// NewClusterRegistry 初始化多集群注册中心
+func NewClusterRegistry() *ClusterRegistry {
+	return &ClusterRegistry{
+		clusters: make(map[string]*cluster),
+	}
+}
+
+// Register 通过 kubeConfig 内容注册集群, 同名集群存在时替换其凭证
+func (r *ClusterRegistry) Register(name, kubeConfig string, opts ...Option) error {
+	cfg, err := initClient(kubeConfig)
+	if err != nil {
+		return err
+	}
+	return r.RegisterConfig(name, cfg, opts...)
+}
+
+// RegisterContext 通过 kubeConfig 内容中指定的 context 注册集群
+func (r *ClusterRegistry) RegisterContext(name, kubeConfig, contextName string, opts ...Option) error {
+	cfg, err := KubeConfigWithContext(kubeConfig, contextName)
+	if err != nil {
+		return err
+	}
+	return r.RegisterConfig(name, cfg, opts...)
+}
+
+// RegisterFile 通过 kubeConfig 文件路径注册集群, contextName 为空时使用 current-context
+func (r *ClusterRegistry) RegisterFile(name, path, contextName string, opts ...Option) error {
+	cfg, err := KubeConfigFromFile(path, contextName)
+	if err != nil {
+		return err
+	}
+	return r.RegisterConfig(name, cfg, opts...)
+}
+
+// RegisterInCluster 使用 Pod 内的 ServiceAccount 注册集群
+func (r *ClusterRegistry) RegisterInCluster(name string, opts ...Option) error {
+	cfg, err := InClusterConfig()
+	if err != nil {
+		return err
+	}
+	return r.RegisterConfig(name, cfg, opts...)
+}
+
+// RegisterConfig 通过 rest.Config 注册集群, 构建并缓存 clientSet/dynamic/discovery client
+func (r *ClusterRegistry) RegisterConfig(name string, cfg *rest.Config, opts ...Option) error {
+	if name == "" {
+		return fmt.Errorf("cluster name is empty")
+	}
+	cfg, err := applyOptions(cfg, opts...)
+	if err != nil {
+		return err
+	}
+	cl, err := newCluster(name, cfg)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.clusters[name]; ok {
+		old.stopCaches()
+	}
+	r.clusters[name] = cl
+	return nil
+}
+
+// Remove 移除集群并停止其缓存, 集群不存在时忽略
+func (r *ClusterRegistry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cl, ok := r.clusters[name]; ok {
+		cl.stopCaches()
+	}
+	delete(r.clusters, name)
+}
+
+// StartCache 为集群启动指定 namespace 范围的 informer 缓存并等待同步, namespace 为空时缓存全部 namespace
+// 同步超时返回错误, 但缓存继续运行, 同步完成前的查询直接访问 apiserver
+func (r *ClusterRegistry) StartCache(ctx context.Context, name, namespace string, resync time.Duration) (*Cache, error) {
+	r.mu.Lock()
+	cl, ok := r.clusters[name]
+	if !ok {
+		r.mu.Unlock()
+		return nil, notRegistered(name)
+	}
+	c, ok := cl.caches[namespace]
+	if !ok {
+		c = NewCache(cl.clientSet, namespace, resync)
+		c.Start()
+		cl.caches[namespace] = c
+	}
+	r.mu.Unlock()
+
+	if err := c.WaitForSync(ctx); err != nil {
+		var e *Error
+		if errors.As(err, &e) {
+			e.Cluster = name
+		}
+		return c, err
+	}
+	return c, nil
+}
+
+// StopCache 停止集群指定 namespace 范围的缓存
+func (r *ClusterRegistry) StopCache(name, namespace string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cl, ok := r.clusters[name]
+	if !ok {
+		return
+	}
+	if c, ok := cl.caches[namespace]; ok {
+		c.Stop()
+		delete(cl.caches, namespace)
+	}
+}
+
+// CacheStats 返回集群全部缓存的状态, 按 namespace 排序
+func (r *ClusterRegistry) CacheStats(name string) ([]CacheStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cl, ok := r.clusters[name]
+	if !ok {
+		return nil, notRegistered(name)
+	}
+	stats := make([]CacheStats, 0, len(cl.caches))
+	for _, c := range cl.caches {
+		stats = append(stats, c.Stats())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Namespace < stats[j].Namespace })
+	return stats, nil
+}
+
+// Has 判断集群是否已注册
+func (r *ClusterRegistry) Has(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.clusters[name]
+	return ok
+}
+
+// Names 返回已注册的集群名称, 按字母排序
+func (r *ClusterRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.clusters))
+	for name := range r.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Config 返回集群 rest.Config 的副本
+func (r *ClusterRegistry) Config(name string) (*rest.Config, error) {
+	cl, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return rest.CopyConfig(cl.config), nil
+}
+
+// Kubernetes 返回集群缓存的原生 clientSet
+func (r *ClusterRegistry) Kubernetes(name string) (*kubernetes.Clientset, error) {
+	cl, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return cl.clientSet, nil
+}
+
+// Dynamic 返回集群缓存的原生 dynamic client
+func (r *ClusterRegistry) Dynamic(name string) (dynamic.Interface, error) {
+	cl, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return cl.dynamic, nil
+}
+
+// Discovery 返回集群缓存的 discovery client
+func (r *ClusterRegistry) Discovery(name string) (*discovery.DiscoveryClient, error) {
+	cl, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return cl.discovery, nil
+}
+
+// ClientSet 返回集群的 clientSetClient, 已启动全部 namespace 的缓存时优先从缓存读取
+func (r *ClusterRegistry) ClientSet(name string) (*clientSetClient, error) {
+	return r.NamespaceClientSet(name, "")
+}
+
+// NamespaceClientSet 返回集群的 clientSetClient, 优先使用该 namespace 的缓存, 其次使用全部 namespace 的缓存
+func (r *ClusterRegistry) NamespaceClientSet(name, namespace string) (*clientSetClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cl, ok := r.clusters[name]
+	if !ok {
+		return nil, notRegistered(name)
+	}
+	c, ok := cl.caches[namespace]
+	if !ok {
+		c = cl.caches[""]
+	}
+	return &clientSetClient{
+		ClientSet:  cl.clientSet,
+		KubeConfig: cl.config,
+		Cluster:    cl.name,
+		Cache:      c,
+		Metrics:    cl.metrics,
+	}, nil
+}
+
+// DynamicClient 返回集群的 dynamicClient
+func (r *ClusterRegistry) DynamicClient(name string) (*dynamicClient, error) {
+	cl, err := r.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamicClient{
+		DynamicClient:   cl.dynamic,
+		DiscoveryClient: cl.discovery,
+		KubeConfig:      cl.config,
+		Cluster:         cl.name,
+	}, nil
+}
+
+func (r *ClusterRegistry) get(name string) (*cluster, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cl, ok := r.clusters[name]
+	if !ok {
+		return nil, notRegistered(name)
+	}
+	return cl, nil
+}
+
+func notRegistered(name string) error {
+	return &Error{Reason: ReasonNotFound, Cluster: name, Err: fmt.Errorf("cluster %s not registered", name)}
+}
+
+func (cl *cluster) stopCaches() {
+	for _, c := range cl.caches {
+		c.Stop()
+	}
+}
+
+func newCluster(name string, cfg *rest.Config) (*cluster, error) {
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dc, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	disc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &cluster{
+		name:      name,
+		config:    cfg,
+		clientSet: clientSet,
+		dynamic:   dc,
+		discovery: disc,
+		metrics:   newRESTMetricsSource(clientSet.Discovery().RESTClient()),
+		caches:    make(map[string]*Cache),
+	}, nil
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterRegistry(t *testing.T) {
+	tests := []struct {
+		name       string
+		clusters   map[string]string
+		remove     []string
+		expectErr  bool
+		expectName []string
+	}{
+		{
+			name:       "register clusters",
+			clusters:   map[string]string{"dmz": dmzClusterConfig, "online": dmzClusterConfig},
+			expectName: []string{"dmz", "online"},
+		},
+		{
+			name:       "remove cluster",
+			clusters:   map[string]string{"dmz": dmzClusterConfig, "online": dmzClusterConfig},
+			remove:     []string{"online", "not-exist"},
+			expectName: []string{"dmz"},
+		},
+		{
+			name:      "invalid kubeconfig",
+			clusters:  map[string]string{"bad": "::not yaml"},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := NewClusterRegistry()
+			var err error
+			for name, kubeConfig := range test.clusters {
+				if e := r.Register(name, kubeConfig); e != nil {
+					err = e
+				}
+			}
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			for _, name := range test.remove {
+				r.Remove(name)
+			}
+			assert.EqualValues(t, test.expectName, r.Names())
+			for _, name := range test.expectName {
+				cs, err := r.ClientSet(name)
+				assert.NoError(t, err)
+				assert.NotNil(t, cs.KubeConfig)
+				again, err := r.ClientSet(name)
+				assert.NoError(t, err)
+				assert.NotNil(t, cs.Metrics)
+				assert.True(t, cs.Metrics == again.Metrics)
+				dc, err := r.DynamicClient(name)
+				assert.NoError(t, err)
+				assert.NotNil(t, dc.DiscoveryClient)
+			}
+		})
+	}
+}
+
+func TestClusterRegistryCache(t *testing.T) {
+	r := NewClusterRegistry()
+	assert.NoError(t, r.Register("dmz", dmzClusterConfig))
+
+	first, err := r.Kubernetes("dmz")
+	assert.NoError(t, err)
+	second, err := r.Kubernetes("dmz")
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// 替换凭证后返回新的 client
+	assert.NoError(t, r.Register("dmz", dmzClusterConfig))
+	third, err := r.Kubernetes("dmz")
+	assert.NoError(t, err)
+	assert.NotSame(t, first, third)
+
+	_, err = r.Kubernetes("not-exist")
+	assert.Error(t, err)
+}
+
+func TestClusterRegistryConcurrent(t *testing.T) {
+	r := NewClusterRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_ = r.Register("dmz", dmzClusterConfig)
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = r.ClientSet("dmz")
+			_ = r.Names()
+		}()
+	}
+	wg.Wait()
+	assert.True(t, r.Has("dmz"))
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	networkv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultBackoff 默认重试策略: 最多 5 次, 间隔从 200ms 开始指数增长, 最长 5s, 并加入随机抖动
+var DefaultBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 200 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+	Cap:      5 * time.Second,
+}
+
+// Retry 执行 fn, 当 retriable(err) 为 true 时按 backoff 重试;
+// 等待重试期间 ctx 取消或超时返回 Timeout 错误, 可以通过 errors.Is 判断 ctx.Err(), 错误信息包含最后一次的错误
+func Retry(ctx context.Context, backoff wait.Backoff, retriable func(error) bool, fn func() error) error {
+	attempts := backoff.Steps
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			timer := time.NewTimer(backoff.Step())
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return NewError(ReasonTimeout, "", "", "", fmt.Errorf("%w, last error: %v", ctx.Err(), err))
+			case <-timer.C:
+			}
+		}
+		if err = fn(); err == nil || !retriable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// RetryOnTransient 重试 429/5xx/超时/连接重置等临时错误
+func RetryOnTransient(ctx context.Context, fn func() error) error {
+	return Retry(ctx, DefaultBackoff, IsRetryable, fn)
+}
+
+// retryUpdate 用于 get -> mutate -> update 流程, 409 冲突时重新获取对象并再次修改;
+// get 必须直接访问 apiserver, 缓存可能尚未收到新版本, 会反复返回同一个 resourceVersion
+func retryUpdate(ctx context.Context, fn func() error) error {
+	return Retry(ctx, DefaultBackoff, func(err error) bool {
+		return IsConflict(err) || IsRetryable(err)
+	}, fn)
+}
+
+// DeploymentUpdateWithRetry 获取最新的 deployment 并执行 mutate 后更新, 冲突或临时错误时自动重试
+func (c *clientSetClient) DeploymentUpdateWithRetry(namespace, name string, mutate func(*appsv1.Deployment) error) (*appsv1.Deployment, error) {
+	return c.DeploymentUpdateWithRetryContext(c.defaultContext(), namespace, name, mutate)
+}
+
+func (c *clientSetClient) DeploymentUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*appsv1.Deployment) error) (result *appsv1.Deployment, err error) {
+	err = retryUpdate(ctx, func() error {
+		deploy, err := c.ClientSet.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.wrapError(err, "Deployment", namespace, name)
+		}
+		if err = mutate(deploy); err != nil {
+			return err
+		}
+		result, err = c.DeploymentUpdateContext(ctx, namespace, deploy)
+		return err
+	})
+	return
+}
+
+func (c *clientSetClient) StatefulSetUpdateWithRetry(namespace, name string, mutate func(*appsv1.StatefulSet) error) (*appsv1.StatefulSet, error) {
+	return c.StatefulSetUpdateWithRetryContext(c.defaultContext(), namespace, name, mutate)
+}
+
+func (c *clientSetClient) StatefulSetUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*appsv1.StatefulSet) error) (result *appsv1.StatefulSet, err error) {
+	err = retryUpdate(ctx, func() error {
+		sts, err := c.ClientSet.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.wrapError(err, "StatefulSet", namespace, name)
+		}
+		if err = mutate(sts); err != nil {
+			return err
+		}
+		result, err = c.StatefulSetUpdateContext(ctx, namespace, sts)
+		return err
+	})
+	return
+}
+
+func (c *clientSetClient) DaemonSetUpdateWithRetry(namespace, name string, mutate func(*appsv1.DaemonSet) error) (*appsv1.DaemonSet, error) {
+	return c.DaemonSetUpdateWithRetryContext(c.defaultContext(), namespace, name, mutate)
+}
+
+func (c *clientSetClient) DaemonSetUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*appsv1.DaemonSet) error) (result *appsv1.DaemonSet, err error) {
+	err = retryUpdate(ctx, func() error {
+		ds, err := c.ClientSet.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.wrapError(err, "DaemonSet", namespace, name)
+		}
+		if err = mutate(ds); err != nil {
+			return err
+		}
+		result, err = c.DaemonSetUpdateContext(ctx, namespace, ds)
+		return err
+	})
+	return
+}
+
+func (c *clientSetClient) ServiceUpdateWithRetry(namespace, name string, mutate func(*apiv1.Service) error) (*apiv1.Service, error) {
+	return c.ServiceUpdateWithRetryContext(c.defaultContext(), namespace, name, mutate)
+}
+
+func (c *clientSetClient) ServiceUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*apiv1.Service) error) (result *apiv1.Service, err error) {
+	err = retryUpdate(ctx, func() error {
+		svc, err := c.ClientSet.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.wrapError(err, "Service", namespace, name)
+		}
+		if err = mutate(svc); err != nil {
+			return err
+		}
+		result, err = c.ServiceUpdateContext(ctx, namespace, svc)
+		return err
+	})
+	return
+}
+
+func (c *clientSetClient) IngressUpdateWithRetry(namespace, name string, mutate func(*networkv1.Ingress) error) (*networkv1.Ingress, error) {
+	return c.IngressUpdateWithRetryContext(c.defaultContext(), namespace, name, mutate)
+}
+
+func (c *clientSetClient) IngressUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*networkv1.Ingress) error) (result *networkv1.Ingress, err error) {
+	err = retryUpdate(ctx, func() error {
+		ing, err := c.ClientSet.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.wrapError(err, "Ingress", namespace, name)
+		}
+		if err = mutate(ing); err != nil {
+			return err
+		}
+		result, err = c.IngressUpdateContext(ctx, namespace, ing)
+		return err
+	})
+	return
+}
+
+func (c *clientSetClient) ConfigmapUpdateWithRetry(namespace, name string, mutate func(*apiv1.ConfigMap) error) (*apiv1.ConfigMap, error) {
+	return c.ConfigmapUpdateWithRetryContext(c.defaultContext(), namespace, name, mutate)
+}
+
+func (c *clientSetClient) ConfigmapUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*apiv1.ConfigMap) error) (result *apiv1.ConfigMap, err error) {
+	err = retryUpdate(ctx, func() error {
+		cm, err := c.ClientSet.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.wrapError(err, "ConfigMap", namespace, name)
+		}
+		if err = mutate(cm); err != nil {
+			return err
+		}
+		result, err = c.ConfigmapUpdateContext(ctx, namespace, cm)
+		return err
+	})
+	return
+}
+
+func (c *clientSetClient) SecretUpdateWithRetry(namespace, name string, mutate func(*apiv1.Secret) error) (*apiv1.Secret, error) {
+	return c.SecretUpdateWithRetryContext(c.defaultContext(), namespace, name, mutate)
+}
+
+func (c *clientSetClient) SecretUpdateWithRetryContext(ctx context.Context, namespace, name string, mutate func(*apiv1.Secret) error) (result *apiv1.Secret, err error) {
+	err = retryUpdate(ctx, func() error {
+		sec, err := c.ClientSet.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.wrapError(err, "Secret", namespace, name)
+		}
+		if err = mutate(sec); err != nil {
+			return err
+		}
+		result, err = c.SecretUpdateContext(ctx, namespace, sec)
+		return err
+	})
+	return
+}
+
+// UpdateWithRetry 获取最新的资源并执行 mutate 后更新, 冲突或临时错误时自动重试
+func (c *dynamicClient) UpdateWithRetry(apiVersion, kind, namespace, name string, mutate func(*unstructured.Unstructured) error) (*unstructured.Unstructured, error) {
+	return c.UpdateWithRetryContext(context.TODO(), apiVersion, kind, namespace, name, mutate)
+}
+
+func (c *dynamicClient) UpdateWithRetryContext(ctx context.Context, apiVersion, kind, namespace, name string, mutate func(*unstructured.Unstructured) error) (result *unstructured.Unstructured, err error) {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	b, err := json.Marshal(u)
+	if err != nil {
+		return nil, NewError(ReasonParseFailed, kind, namespace, name, err)
+	}
+	u, mp, err := c.render(b)
+	if err != nil {
+		return nil, err
+	}
+	resREST, err := c.resourceREST(u, mp)
+	if err != nil {
+		return nil, err
+	}
+	err = retryUpdate(ctx, func() error {
+		obj, err := resREST.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return c.wrapError(err, u, name)
+		}
+		if err = mutate(obj); err != nil {
+			return err
+		}
+		result, err = resREST.Update(ctx, obj, metav1.UpdateOptions{})
+		return c.wrapError(err, u, name)
+	})
+	return
+}
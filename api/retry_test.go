@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRetry(t *testing.T) {
+	backoff := wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 2, Jitter: 0.1}
+	transient := apierrors.NewTooManyRequests("slow down", 1)
+	tests := []struct {
+		name         string
+		errs         []error
+		expectErr    error
+		expectCalled int
+	}{
+		{name: "success", errs: []error{nil}, expectCalled: 1},
+		{name: "transient then success", errs: []error{transient, nil}, expectCalled: 2},
+		{name: "exhausted", errs: []error{transient, transient, transient, nil}, expectErr: transient, expectCalled: 3},
+		{name: "not retryable", errs: []error{errors.New("bad input"), nil}, expectErr: errors.New("bad input"), expectCalled: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var called int
+			err := Retry(context.Background(), backoff, IsRetryable, func() error {
+				called++
+				return test.errs[called-1]
+			})
+			assert.EqualValues(t, test.expectErr, err)
+			assert.EqualValues(t, test.expectCalled, called)
+		})
+	}
+}
+
+func TestRetryCanceled(t *testing.T) {
+	backoff := wait.Backoff{Steps: 3, Duration: time.Minute}
+	unavailable := apierrors.NewServiceUnavailable("etcd leader changed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var called int
+	err := Retry(ctx, backoff, IsRetryable, func() error {
+		called++
+		cancel()
+		return unavailable
+	})
+	assert.EqualValues(t, 1, called)
+	assert.True(t, IsTimeout(err))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Contains(t, err.Error(), "etcd leader changed")
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = Retry(ctx, backoff, IsRetryable, func() error { return unavailable })
+	assert.True(t, IsTimeout(err))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestDeploymentUpdateWithRetry(t *testing.T) {
+	c := NewFakeClientSet(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "cn-online"},
+	})
+	// 第一次 update 返回 409, 模拟对象被 controller 修改
+	var conflicted bool
+	c.ClientSet.(*fake.Clientset).PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicted {
+			return false, nil, nil
+		}
+		conflicted = true
+		return true, nil, apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "nginx", errors.New("modified"))
+	})
+
+	var mutated int
+	deploy, err := c.DeploymentUpdateWithRetry("cn-online", "nginx", func(d *appsv1.Deployment) error {
+		mutated++
+		d.Labels = map[string]string{"version": "v2"}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.EqualValues(t, 2, mutated)
+	assert.EqualValues(t, "v2", deploy.Labels["version"])
+
+	_, err = c.DeploymentUpdateWithRetry("cn-online", "nginx", func(d *appsv1.Deployment) error {
+		return errors.New("abort")
+	})
+	assert.EqualError(t, err, "abort")
+}
+
+func TestDeploymentUpdateWithRetryCached(t *testing.T) {
+	c := NewFakeClientSet(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "cn-online", ResourceVersion: "1"},
+	})
+	cache := NewCache(c.ClientSet, "cn-online", 0)
+	defer cache.Stop()
+	cache.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cache.WaitForSync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c.Cache = cache
+
+	// apiserver 上的对象已被 controller 修改, 缓存仍是旧版本; update 按 resourceVersion 检查冲突
+	current := "2"
+	fakeClient := c.ClientSet.(*fake.Clientset)
+	fakeClient.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "cn-online", ResourceVersion: current},
+		}, nil
+	})
+	fakeClient.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deploy := action.(k8stesting.UpdateAction).GetObject().(*appsv1.Deployment).DeepCopy()
+		if deploy.ResourceVersion != current {
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "nginx", errors.New("modified"))
+		}
+		current = "3"
+		deploy.ResourceVersion = current
+		return true, deploy, nil
+	})
+
+	cached, err := c.DeploymentGet("cn-online", "nginx")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", cached.ResourceVersion)
+
+	deploy, err := c.DeploymentUpdateWithRetryContext(ctx, "cn-online", "nginx", func(d *appsv1.Deployment) error {
+		d.Labels = map[string]string{"version": "v2"}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "3", deploy.ResourceVersion)
+	assert.Equal(t, "v2", deploy.Labels["version"])
+}
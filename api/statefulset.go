@@ -0,0 +1,311 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zhengyansheng/common"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// restartedAtAnnotation 与 kubectl rollout restart 使用相同的注解
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// StatefulSetCreate 创建 statefulSet
+func (c *clientSetClient) StatefulSetCreate(namespace string, statefulSet *appsv1.StatefulSet) (*appsv1.StatefulSet, error) {
+	return c.StatefulSetCreateContext(c.defaultContext(), namespace, statefulSet)
+}
+
+func (c *clientSetClient) StatefulSetCreateContext(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) (*appsv1.StatefulSet, error) {
+	sts, err := c.ClientSet.AppsV1().StatefulSets(namespace).Create(ctx, statefulSet, metav1.CreateOptions{})
+	return sts, c.wrapError(err, "StatefulSet", namespace, statefulSet.Name)
+}
+
+// StatefulSetGet 查询单个 statefulSet 原生数据
+func (c *clientSetClient) StatefulSetGet(namespace, name string) (*appsv1.StatefulSet, error) {
+	return c.StatefulSetGetContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) StatefulSetGetContext(ctx context.Context, namespace, name string) (*appsv1.StatefulSet, error) {
+	sts, err := c.ClientSet.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	return sts, c.wrapError(err, "StatefulSet", namespace, name)
+}
+
+func (c *clientSetClient) StatefulSetUpdate(namespace string, statefulSet *appsv1.StatefulSet) (*appsv1.StatefulSet, error) {
+	return c.StatefulSetUpdateContext(c.defaultContext(), namespace, statefulSet)
+}
+
+func (c *clientSetClient) StatefulSetUpdateContext(ctx context.Context, namespace string, statefulSet *appsv1.StatefulSet) (*appsv1.StatefulSet, error) {
+	sts, err := c.ClientSet.AppsV1().StatefulSets(namespace).Update(ctx, statefulSet, metav1.UpdateOptions{})
+	return sts, c.wrapError(err, "StatefulSet", namespace, statefulSet.Name)
+}
+
+// StatefulSetDelete 删除 statefulSet 及其 pod, volumeClaimTemplates 创建的 pvc 不会被删除
+func (c *clientSetClient) StatefulSetDelete(namespace, name string) error {
+	return c.StatefulSetDeleteContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) StatefulSetDeleteContext(ctx context.Context, namespace, name string) error {
+	deletePolicy := metav1.DeletePropagationForeground
+	err := c.ClientSet.AppsV1().StatefulSets(namespace).Delete(ctx, name, metav1.DeleteOptions{
+		PropagationPolicy: &deletePolicy,
+	})
+	return c.wrapError(err, "StatefulSet", namespace, name)
+}
+
+// StatefulSetUpdateReplicas 修改 statefulSet 副本数
+func (c *clientSetClient) StatefulSetUpdateReplicas(namespace, name string, replicas int) (*appsv1.StatefulSet, error) {
+	return c.StatefulSetUpdateReplicasContext(c.defaultContext(), namespace, name, replicas)
+}
+
+func (c *clientSetClient) StatefulSetUpdateReplicasContext(ctx context.Context, namespace, name string, replicas int) (*appsv1.StatefulSet, error) {
+	data := map[string]map[string]int{
+		"spec": {
+			"replicas": replicas,
+		},
+	}
+	byteMarshal, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.statefulSetPatch(ctx, namespace, name, types.MergePatchType, byteMarshal)
+}
+
+// StatefulSetUpdatePartition 设置 RollingUpdate 的 partition, 只有序号 >= partition 的 pod 会更新到新版本
+// 逐步调小 partition 即可实现分批发布, partition 为 0 时全部更新
+func (c *clientSetClient) StatefulSetUpdatePartition(namespace, name string, partition int32) (*appsv1.StatefulSet, error) {
+	return c.StatefulSetUpdatePartitionContext(c.defaultContext(), namespace, name, partition)
+}
+
+func (c *clientSetClient) StatefulSetUpdatePartitionContext(ctx context.Context, namespace, name string, partition int32) (*appsv1.StatefulSet, error) {
+	if partition < 0 {
+		return nil, NewError(ReasonInvalid, "StatefulSet", namespace, name, fmt.Errorf("partition %d must be >= 0", partition))
+	}
+	data := []byte(fmt.Sprintf(`{"spec":{"updateStrategy":{"type":"RollingUpdate","rollingUpdate":{"partition":%d}}}}`, partition))
+	return c.statefulSetPatch(ctx, namespace, name, types.MergePatchType, data)
+}
+
+// StatefulSetRestart 滚动重启 statefulSet, 等同于 kubectl rollout restart
+func (c *clientSetClient) StatefulSetRestart(namespace, name string) (*appsv1.StatefulSet, error) {
+	return c.StatefulSetRestartContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) StatefulSetRestartContext(ctx context.Context, namespace, name string) (*appsv1.StatefulSet, error) {
+	return c.statefulSetPatch(ctx, namespace, name, types.MergePatchType, restartPatch())
+}
+
+func (c *clientSetClient) statefulSetPatch(ctx context.Context, namespace, name string, pt types.PatchType, data []byte) (*appsv1.StatefulSet, error) {
+	sts, err := c.ClientSet.AppsV1().StatefulSets(namespace).Patch(ctx, name, pt, data, metav1.PatchOptions{})
+	return sts, c.wrapError(err, "StatefulSet", namespace, name)
+}
+
+// StatefulSetGetFormat 查询单个 statefulSet 格式化数据, 包含每个序号 pod 的就绪状态
+func (c *clientSetClient) StatefulSetGetFormat(namespace, name string) (StatefulSetInfo, error) {
+	return c.StatefulSetGetFormatContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) StatefulSetGetFormatContext(ctx context.Context, namespace, name string) (detail StatefulSetInfo, err error) {
+	sts, err := c.StatefulSetGetContext(ctx, namespace, name)
+	if err != nil {
+		return
+	}
+	pods, err := c.statefulSetPods(ctx, sts)
+	if err != nil {
+		return
+	}
+	return statefulSetInfo(sts, pods), nil
+}
+
+// StatefulSetListFormat 获取 statefulSet 格式化后的数据, namespace 下的 pod 只查询一次
+func (c *clientSetClient) StatefulSetListFormat(namespace string, opts ...ListOpts) ([]StatefulSetInfo, error) {
+	return c.StatefulSetListFormatContext(c.defaultContext(), namespace, opts...)
+}
+
+func (c *clientSetClient) StatefulSetListFormatContext(ctx context.Context, namespace string, opts ...ListOpts) (statefulSets []StatefulSetInfo, err error) {
+	list, err := c.StatefulSetListContext(ctx, namespace, opts...)
+	if err != nil || len(list.Items) == 0 {
+		return
+	}
+	podList, err := c.PodListContext(ctx, namespace)
+	if err != nil {
+		return
+	}
+	for i := range list.Items {
+		sts := &list.Items[i]
+		statefulSets = append(statefulSets, statefulSetInfo(sts, ownedOrdinalPods(sts, podList.Items)))
+	}
+	return
+}
+
+// StatefulSetPods 按序号顺序返回 statefulSet 的 pod
+func (c *clientSetClient) StatefulSetPods(namespace, name string) ([]apiv1.Pod, error) {
+	return c.StatefulSetPodsContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) StatefulSetPodsContext(ctx context.Context, namespace, name string) ([]apiv1.Pod, error) {
+	sts, err := c.StatefulSetGetContext(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return c.statefulSetPods(ctx, sts)
+}
+
+func (c *clientSetClient) statefulSetPods(ctx context.Context, sts *appsv1.StatefulSet) ([]apiv1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(sts.Spec.Selector)
+	if err != nil {
+		return nil, NewError(ReasonInvalid, "StatefulSet", sts.Namespace, sts.Name, err)
+	}
+	podList, err := c.PodListContext(ctx, sts.Namespace, ListOpts{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	return ownedOrdinalPods(sts, podList.Items), nil
+}
+
+// StatefulSetPvcs 返回 volumeClaimTemplates 创建的 pvc, 包括缩容后保留下来的 pvc, 按模板名称和序号排序
+func (c *clientSetClient) StatefulSetPvcs(namespace, name string) ([]apiv1.PersistentVolumeClaim, error) {
+	return c.StatefulSetPvcsContext(c.defaultContext(), namespace, name)
+}
+
+func (c *clientSetClient) StatefulSetPvcsContext(ctx context.Context, namespace, name string) ([]apiv1.PersistentVolumeClaim, error) {
+	sts, err := c.StatefulSetGetContext(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(sts.Spec.VolumeClaimTemplates) == 0 {
+		return nil, nil
+	}
+	pvcList, err := c.PvcListContext(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// pvc 名称规则: <template>-<statefulSet>-<ordinal>
+	type ordinalPvc struct {
+		template int
+		ordinal  int
+		pvc      apiv1.PersistentVolumeClaim
+	}
+	var matched []ordinalPvc
+	for _, pvc := range pvcList.Items {
+		for i, tpl := range sts.Spec.VolumeClaimTemplates {
+			ordinal, ok := parseOrdinal(pvc.Name, tpl.Name+"-"+sts.Name)
+			if ok {
+				matched = append(matched, ordinalPvc{template: i, ordinal: ordinal, pvc: pvc})
+				break
+			}
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].template != matched[j].template {
+			return matched[i].template < matched[j].template
+		}
+		return matched[i].ordinal < matched[j].ordinal
+	})
+	pvcs := make([]apiv1.PersistentVolumeClaim, 0, len(matched))
+	for _, m := range matched {
+		pvcs = append(pvcs, m.pvc)
+	}
+	return pvcs, nil
+}
+
+// ownedOrdinalPods 过滤出属于 statefulSet 的 pod 并按序号排序
+func ownedOrdinalPods(sts *appsv1.StatefulSet, pods []apiv1.Pod) []apiv1.Pod {
+	type ordinalPod struct {
+		ordinal int
+		pod     apiv1.Pod
+	}
+	var matched []ordinalPod
+	for _, pod := range pods {
+		if ref := metav1.GetControllerOf(&pod); ref == nil || ref.UID != sts.UID {
+			continue
+		}
+		if ordinal, ok := parseOrdinal(pod.Name, sts.Name); ok {
+			matched = append(matched, ordinalPod{ordinal: ordinal, pod: pod})
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ordinal < matched[j].ordinal })
+	result := make([]apiv1.Pod, 0, len(matched))
+	for _, m := range matched {
+		result = append(result, m.pod)
+	}
+	return result
+}
+
+// parseOrdinal 解析 <parent>-<ordinal> 格式名称中的序号
+func parseOrdinal(name, parent string) (int, bool) {
+	if !strings.HasPrefix(name, parent+"-") {
+		return 0, false
+	}
+	ordinal, err := strconv.Atoi(strings.TrimPrefix(name, parent+"-"))
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+	return ordinal, true
+}
+
+func statefulSetInfo(sts *appsv1.StatefulSet, pods []apiv1.Pod) StatefulSetInfo {
+	createTime := sts.CreationTimestamp.Format(common.SecLocalTimeFormat)
+	currentTime := time.Now().Format(common.SecLocalTimeFormat)
+	info := StatefulSetInfo{
+		Name:            sts.Name,
+		Age:             common.SubTime(createTime, currentTime),
+		Replicas:        sts.Status.Replicas,
+		ReadyReplicas:   sts.Status.ReadyReplicas,
+		CurrentReplicas: sts.Status.CurrentReplicas,
+		UpdatedReplicas: sts.Status.UpdatedReplicas,
+		CurrentRevision: sts.Status.CurrentRevision,
+		UpdateRevision:  sts.Status.UpdateRevision,
+		UpdateStrategy:  string(sts.Spec.UpdateStrategy.Type),
+	}
+	if sts.Spec.Replicas != nil {
+		info.DesiredReplicas = *sts.Spec.Replicas
+	}
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil {
+		info.Partition = *ru.Partition
+	}
+	for _, pod := range pods {
+		ordinal, _ := parseOrdinal(pod.Name, sts.Name)
+		info.Pods = append(info.Pods, OrdinalPod{
+			Name:     pod.Name,
+			Ordinal:  ordinal,
+			Ready:    podReady(&pod),
+			Phase:    string(pod.Status.Phase),
+			Revision: pod.Labels[appsv1.StatefulSetRevisionLabel],
+			NodeName: pod.Spec.NodeName,
+		})
+	}
+	switch {
+	case info.DesiredReplicas != info.ReadyReplicas:
+		info.Phase = "Progressing"
+	case info.UpdateRevision != "" && info.CurrentRevision != info.UpdateRevision:
+		info.Phase = "Updating"
+	default:
+		info.Phase = "Healthy"
+	}
+	return info
+}
+
+// podReady pod 的 Ready condition 是否为 True
+func podReady(pod *apiv1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == apiv1.PodReady {
+			return cond.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// restartPatch 修改 pod 模板的注解触发滚动重启
+func restartPatch() []byte {
+	return []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{%q:%q}}}}}`,
+		restartedAtAnnotation, time.Now().Format(time.RFC3339)))
+}
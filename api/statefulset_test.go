@@ -0,0 +1,100 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestStatefulSet(t *testing.T) {
+	replicas := int32(3)
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "cn-online", UID: types.UID("sts-uid")},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "mysql"}},
+			VolumeClaimTemplates: []apiv1.PersistentVolumeClaim{
+				{ObjectMeta: metav1.ObjectMeta{Name: "data"}},
+			},
+		},
+		Status: appsv1.StatefulSetStatus{Replicas: 3, ReadyReplicas: 2, CurrentRevision: "mysql-v1", UpdateRevision: "mysql-v1"},
+	}
+	isController := true
+	pod := func(name string, ready apiv1.ConditionStatus) *apiv1.Pod {
+		return &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "cn-online",
+				Labels:          map[string]string{"app": "mysql", appsv1.StatefulSetRevisionLabel: "mysql-v1"},
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "mysql", UID: sts.UID, Controller: &isController}},
+			},
+			Status: apiv1.PodStatus{Conditions: []apiv1.PodCondition{{Type: apiv1.PodReady, Status: ready}}},
+		}
+	}
+	pvc := func(name string) *apiv1.PersistentVolumeClaim {
+		return &apiv1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "cn-online"}}
+	}
+	c := NewFakeClientSet([]runtime.Object{
+		sts,
+		pod("mysql-10", apiv1.ConditionTrue),
+		pod("mysql-2", apiv1.ConditionFalse),
+		pod("mysql-0", apiv1.ConditionTrue),
+		// 同一 selector 但不属于该 statefulSet
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "mysql-backup", Namespace: "cn-online", Labels: map[string]string{"app": "mysql"}}},
+		pvc("data-mysql-1"),
+		pvc("data-mysql-0"),
+		pvc("data-mysql-backup"),
+		pvc("logs-mysql-0"),
+	}...)
+
+	pods, err := c.StatefulSetPods("cn-online", "mysql")
+	assert.NoError(t, err)
+	var names []string
+	for _, p := range pods {
+		names = append(names, p.Name)
+	}
+	assert.EqualValues(t, []string{"mysql-0", "mysql-2", "mysql-10"}, names)
+
+	info, err := c.StatefulSetGetFormat("cn-online", "mysql")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, info.DesiredReplicas)
+	assert.EqualValues(t, "Progressing", info.Phase)
+	assert.Len(t, info.Pods, 3)
+	assert.EqualValues(t, 2, info.Pods[1].Ordinal)
+	assert.False(t, info.Pods[1].Ready)
+	assert.EqualValues(t, "mysql-v1", info.Pods[1].Revision)
+
+	list, err := c.StatefulSetListFormat("cn-online")
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Len(t, list[0].Pods, 3)
+
+	pvcs, err := c.StatefulSetPvcs("cn-online", "mysql")
+	assert.NoError(t, err)
+	assert.Len(t, pvcs, 2)
+	assert.EqualValues(t, "data-mysql-0", pvcs[0].Name)
+	assert.EqualValues(t, "data-mysql-1", pvcs[1].Name)
+
+	updated, err := c.StatefulSetUpdatePartition("cn-online", "mysql", 2)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, *updated.Spec.UpdateStrategy.RollingUpdate.Partition)
+	_, err = c.StatefulSetUpdatePartition("cn-online", "mysql", -1)
+	assert.True(t, IsInvalid(err))
+
+	updated, err = c.StatefulSetUpdateReplicas("cn-online", "mysql", 5)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, *updated.Spec.Replicas)
+
+	updated, err = c.StatefulSetRestart("cn-online", "mysql")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, updated.Spec.Template.Annotations[restartedAtAnnotation])
+
+	assert.NoError(t, c.StatefulSetDelete("cn-online", "mysql"))
+	_, err = c.StatefulSetGet("cn-online", "mysql")
+	assert.True(t, IsNotFound(err))
+}
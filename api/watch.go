@@ -0,0 +1,408 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/api/events/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// DeploymentWatchEvent deployment 变更事件
+// Type 为 watch.Error 时 Err 不为空, 表示 watch 无法继续 (如无权限), 之后 channel 关闭
+type DeploymentWatchEvent struct {
+	Type       watch.EventType
+	Deployment *appsv1.Deployment
+	Err        error
+}
+
+// PodWatchEvent pod 变更事件
+type PodWatchEvent struct {
+	Type watch.EventType
+	Pod  *apiv1.Pod
+	Err  error
+}
+
+// EventWatchEvent event 变更事件
+type EventWatchEvent struct {
+	Type  watch.EventType
+	Event *v1beta1.Event
+	Err   error
+}
+
+// NodeWatchEvent node 变更事件
+type NodeWatchEvent struct {
+	Type watch.EventType
+	Node *apiv1.Node
+	Err  error
+}
+
+// WatchEvent 任意资源的变更事件
+type WatchEvent struct {
+	Type   watch.EventType
+	Object *unstructured.Unstructured
+	Err    error
+}
+
+// watcher list + watch 循环, 负责 resourceVersion 记录, 410 Gone 时重新 list, 连接断开时按 backoff 重连
+// 首次 list 以及重新 list 时, 与已知对象比较后补发 Added/Modified/Deleted 事件, 调用方无需感知重连
+type watcher struct {
+	cluster   string
+	kind      string
+	namespace string
+	opts      metav1.ListOptions
+	list      func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error)
+	watch     func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	// emit 发送事件, ctx 取消时返回 false
+	emit func(t watch.EventType, obj runtime.Object, err error) bool
+
+	rv    string
+	known map[string]runtime.Object
+}
+
+type pendingEvent struct {
+	t   watch.EventType
+	obj runtime.Object
+}
+
+// start 同步执行首次 list, 失败时直接返回错误; 成功后在后台推送事件, 结束时调用 done 关闭 channel
+func (w *watcher) start(ctx context.Context, done func()) error {
+	w.known = make(map[string]runtime.Object)
+	pending, err := w.relist(ctx)
+	if err != nil {
+		return w.wrapError(err)
+	}
+	go func() {
+		defer done()
+		if !w.send(pending) {
+			return
+		}
+		w.run(ctx)
+	}()
+	return nil
+}
+
+func (w *watcher) run(ctx context.Context) {
+	backoff := DefaultBackoff
+	for {
+		received, err := w.watchOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			// resourceVersion 已被 etcd 压缩, 重新 list 并补发差异事件
+			var pending []pendingEvent
+			if pending, err = w.relist(ctx); err == nil && !w.send(pending) {
+				return
+			}
+		}
+		if err != nil && !watchRetryable(err) {
+			w.emit(watch.Error, nil, w.wrapError(err))
+			return
+		}
+		if received && err == nil {
+			backoff = DefaultBackoff
+			continue
+		}
+		timer := time.NewTimer(backoff.Step())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// watchOnce 从当前 resourceVersion 开始 watch, 直到连接关闭或出错, received 表示是否收到过事件
+func (w *watcher) watchOnce(ctx context.Context) (received bool, err error) {
+	opts := w.opts
+	opts.ResourceVersion = w.rv
+	opts.AllowWatchBookmarks = true
+	wi, err := w.watch(ctx, opts)
+	if err != nil {
+		return false, err
+	}
+	defer wi.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return received, ctx.Err()
+		case ev, ok := <-wi.ResultChan():
+			if !ok {
+				return received, nil
+			}
+			received = true
+			switch ev.Type {
+			case watch.Error:
+				return received, apierrors.FromObject(ev.Object)
+			case watch.Bookmark:
+				if acc, err := meta.Accessor(ev.Object); err == nil {
+					w.rv = acc.GetResourceVersion()
+				}
+			case watch.Added, watch.Modified, watch.Deleted:
+				acc, err := meta.Accessor(ev.Object)
+				if err != nil {
+					continue
+				}
+				w.rv = acc.GetResourceVersion()
+				key := objectKey(acc)
+				if ev.Type == watch.Deleted {
+					delete(w.known, key)
+				} else {
+					w.known[key] = ev.Object
+				}
+				if !w.emit(ev.Type, ev.Object, nil) {
+					return received, ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// relist 重新 list, 返回与已知对象比较后的差异事件
+func (w *watcher) relist(ctx context.Context) ([]pendingEvent, error) {
+	opts := w.opts
+	opts.ResourceVersion = ""
+	list, err := w.list(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	listMeta, err := meta.ListAccessor(list)
+	if err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []pendingEvent
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		acc, err := meta.Accessor(item)
+		if err != nil {
+			return nil, err
+		}
+		key := objectKey(acc)
+		seen[key] = true
+		old, ok := w.known[key]
+		switch {
+		case !ok:
+			pending = append(pending, pendingEvent{t: watch.Added, obj: item})
+		case resourceVersion(old) != acc.GetResourceVersion():
+			pending = append(pending, pendingEvent{t: watch.Modified, obj: item})
+		}
+		w.known[key] = item
+	}
+	for key, old := range w.known {
+		if !seen[key] {
+			pending = append(pending, pendingEvent{t: watch.Deleted, obj: old})
+			delete(w.known, key)
+		}
+	}
+	w.rv = listMeta.GetResourceVersion()
+	return pending, nil
+}
+
+func (w *watcher) send(pending []pendingEvent) bool {
+	for _, ev := range pending {
+		if !w.emit(ev.t, ev.obj, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *watcher) wrapError(err error) error {
+	return wrapError(err, w.cluster, w.kind, w.namespace, "")
+}
+
+// watchRetryable 权限/资源不存在/参数错误无法通过重连恢复, 其余错误 (断连/超时/5xx) 均重连
+func watchRetryable(err error) bool {
+	switch ReasonOf(err) {
+	case ReasonForbidden, ReasonUnauthorized, ReasonNotFound, ReasonInvalid:
+		return false
+	}
+	return true
+}
+
+func objectKey(acc metav1.Object) string {
+	if acc.GetNamespace() == "" {
+		return acc.GetName()
+	}
+	return acc.GetNamespace() + "/" + acc.GetName()
+}
+
+func resourceVersion(obj runtime.Object) string {
+	acc, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return acc.GetResourceVersion()
+}
+
+// WatchDeployments 监听 namespace 下的 deployment 变更, 首先推送现有对象的 Added 事件, ctx 取消后 channel 关闭
+func (c *clientSetClient) WatchDeployments(ctx context.Context, namespace string) (<-chan DeploymentWatchEvent, error) {
+	client := c.ClientSet.AppsV1().Deployments(namespace)
+	ch := make(chan DeploymentWatchEvent)
+	w := &watcher{
+		cluster:   c.Cluster,
+		kind:      "Deployment",
+		namespace: namespace,
+		list: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			return client.List(ctx, opts)
+		},
+		watch: client.Watch,
+		emit: func(t watch.EventType, obj runtime.Object, err error) bool {
+			ev := DeploymentWatchEvent{Type: t, Err: err}
+			ev.Deployment, _ = obj.(*appsv1.Deployment)
+			select {
+			case ch <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		},
+	}
+	if err := w.start(ctx, func() { close(ch) }); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// WatchPods 监听 namespace 下匹配 labelSelector 的 pod 变更
+func (c *clientSetClient) WatchPods(ctx context.Context, namespace string, labelSelector map[string]string) (<-chan PodWatchEvent, error) {
+	return c.watchPods(ctx, namespace, labels.FormatLabels(labelSelector))
+}
+
+func (c *clientSetClient) watchPods(ctx context.Context, namespace, labelSelector string) (<-chan PodWatchEvent, error) {
+	client := c.ClientSet.CoreV1().Pods(namespace)
+	ch := make(chan PodWatchEvent)
+	w := &watcher{
+		cluster:   c.Cluster,
+		kind:      "Pod",
+		namespace: namespace,
+		opts:      metav1.ListOptions{LabelSelector: labelSelector},
+		list: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			return client.List(ctx, opts)
+		},
+		watch: client.Watch,
+		emit: func(t watch.EventType, obj runtime.Object, err error) bool {
+			ev := PodWatchEvent{Type: t, Err: err}
+			ev.Pod, _ = obj.(*apiv1.Pod)
+			select {
+			case ch <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		},
+	}
+	if err := w.start(ctx, func() { close(ch) }); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// WatchEvents 监听关联到指定对象的 event, kind 为空时只按名称过滤
+func (c *clientSetClient) WatchEvents(ctx context.Context, namespace, kind, name string) (<-chan EventWatchEvent, error) {
+	client := c.ClientSet.EventsV1beta1().Events(namespace)
+	selector := fields.Set{"regarding.name": name}
+	if kind != "" {
+		selector["regarding.kind"] = kind
+	}
+	ch := make(chan EventWatchEvent)
+	w := &watcher{
+		cluster:   c.Cluster,
+		kind:      "Event",
+		namespace: namespace,
+		opts:      metav1.ListOptions{FieldSelector: selector.String()},
+		list: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			return client.List(ctx, opts)
+		},
+		watch: client.Watch,
+		emit: func(t watch.EventType, obj runtime.Object, err error) bool {
+			ev := EventWatchEvent{Type: t, Err: err}
+			ev.Event, _ = obj.(*v1beta1.Event)
+			select {
+			case ch <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		},
+	}
+	if err := w.start(ctx, func() { close(ch) }); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// WatchNodes 监听 node 变更
+func (c *clientSetClient) WatchNodes(ctx context.Context) (<-chan NodeWatchEvent, error) {
+	client := c.ClientSet.CoreV1().Nodes()
+	ch := make(chan NodeWatchEvent)
+	w := &watcher{
+		cluster: c.Cluster,
+		kind:    "Node",
+		list: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			return client.List(ctx, opts)
+		},
+		watch: client.Watch,
+		emit: func(t watch.EventType, obj runtime.Object, err error) bool {
+			ev := NodeWatchEvent{Type: t, Err: err}
+			ev.Node, _ = obj.(*apiv1.Node)
+			select {
+			case ch <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		},
+	}
+	if err := w.start(ctx, func() { close(ch) }); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// Watch 监听任意资源的变更, namespace 为空时监听全部 namespace 或集群级资源
+func (c *dynamicClient) Watch(ctx context.Context, gvr schema.GroupVersionResource, namespace, labelSelector string) (<-chan WatchEvent, error) {
+	client := c.DynamicClient.Resource(gvr).Namespace(namespace)
+	ch := make(chan WatchEvent)
+	w := &watcher{
+		cluster:   c.Cluster,
+		kind:      gvr.Resource,
+		namespace: namespace,
+		opts:      metav1.ListOptions{LabelSelector: labelSelector},
+		list: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			return client.List(ctx, opts)
+		},
+		watch: client.Watch,
+		emit: func(t watch.EventType, obj runtime.Object, err error) bool {
+			ev := WatchEvent{Type: t, Err: err}
+			ev.Object, _ = obj.(*unstructured.Unstructured)
+			select {
+			case ch <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		},
+	}
+	if err := w.start(ctx, func() { close(ch) }); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestWatchDeployments(t *testing.T) {
+	c := NewFakeClientSet(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "cn-online"},
+	})
+	// 第一次 watch 返回 410 Gone, 期间 nginx 被删除, redis 被创建, 重新 list 后应补发事件
+	expired := watch.NewFake()
+	var watched int32
+	c.ClientSet.(*fake.Clientset).PrependWatchReactor("deployments", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		if atomic.AddInt32(&watched, 1) > 1 {
+			return false, nil, nil
+		}
+		return true, expired, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.WatchDeployments(ctx, "cn-online")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() DeploymentWatchEvent {
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("watch event timeout")
+		}
+		return DeploymentWatchEvent{}
+	}
+
+	ev := next()
+	assert.EqualValues(t, watch.Added, ev.Type)
+	assert.EqualValues(t, "nginx", ev.Deployment.Name)
+
+	assert.NoError(t, c.DeploymentDelete("cn-online", "nginx"))
+	_, err = c.DeploymentCreate("cn-online", &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "cn-online"}})
+	assert.NoError(t, err)
+	expired.Error(&metav1.Status{Status: metav1.StatusFailure, Code: http.StatusGone, Reason: metav1.StatusReasonExpired})
+
+	ev = next()
+	assert.EqualValues(t, watch.Added, ev.Type)
+	assert.EqualValues(t, "redis", ev.Deployment.Name)
+	ev = next()
+	assert.EqualValues(t, watch.Deleted, ev.Type)
+	assert.EqualValues(t, "nginx", ev.Deployment.Name)
+
+	// 重新 watch 后继续收到变更
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&watched) > 1 }, 5*time.Second, 10*time.Millisecond)
+	_, err = c.DeploymentCreate("cn-online", &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "cn-online"}})
+	assert.NoError(t, err)
+	ev = next()
+	assert.EqualValues(t, watch.Added, ev.Type)
+	assert.EqualValues(t, "mysql", ev.Deployment.Name)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-events
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
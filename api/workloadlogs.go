@@ -0,0 +1,360 @@
+package api
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// logMergeWindow 多个日志流合并时的排序窗口, 窗口内收到的日志按时间戳排序后输出
+var logMergeWindow = time.Second
+
+// LogLine 一行日志, Line 不包含换行符和时间戳前缀
+// Err 不为空时表示某个日志流异常结束, 其他日志流不受影响
+type LogLine struct {
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Time      time.Time `json:"time"`
+	Line      string    `json:"line"`
+	Err       error     `json:"-"`
+}
+
+func (l LogLine) String() string {
+	if l.Err != nil {
+		return fmt.Sprintf("[%s/%s] error: %v", l.Pod, l.Container, l.Err)
+	}
+	return fmt.Sprintf("[%s/%s] %s", l.Pod, l.Container, l.Line)
+}
+
+// WorkloadLogs 汇总 Deployment/StatefulSet/DaemonSet/Job 所有 pod 的日志, 按时间戳合并后输出.
+// opts.Container 为空时读取每个 pod 的全部容器 (不含 init 容器), 指定 init 容器名称时读取该 init 容器;
+// opts.Timestamps 会被忽略, 时间戳总是解析到 LogLine.Time.
+// opts.Follow 为 true 时持续跟踪: 滚动升级中新出现的 pod 和重启后的容器会自动接入并从头读取, 删除的 pod 自动断开;
+// 否则读取当前 pod 的日志后关闭 channel. 需要停止跟踪时使用 WorkloadLogsContext
+func (c *clientSetClient) WorkloadLogs(namespace, kind, name string, opts PodLogOpts) (<-chan LogLine, error) {
+	return c.WorkloadLogsContext(c.defaultContext(), namespace, kind, name, opts)
+}
+
+// WorkloadLogsContext ctx 取消时关闭所有日志流和 channel
+func (c *clientSetClient) WorkloadLogsContext(ctx context.Context, namespace, kind, name string, opts PodLogOpts) (<-chan LogLine, error) {
+	if err := opts.validate(); err != nil {
+		e := NewError(ReasonInvalid, kind, namespace, name, err)
+		e.Cluster = c.Cluster
+		return nil, e
+	}
+	selector, ownerUID, err := c.workloadSelector(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	m := &logMerger{
+		c:         c,
+		ctx:       ctx,
+		namespace: namespace,
+		opts:      opts,
+		ownerUID:  ownerUID,
+		startTime: time.Now(),
+		in:        make(chan LogLine, 128),
+		out:       make(chan LogLine),
+		attached:  map[string]string{},
+		pods:      map[types.UID]podLogContext{},
+	}
+
+	if !opts.Follow {
+		pods, err := c.PodListContext(ctx, namespace, ListOpts{LabelSelector: selector})
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		for i := range pods.Items {
+			m.attach(&pods.Items[i])
+		}
+		go func() {
+			m.wg.Wait()
+			close(m.in)
+		}()
+	} else {
+		events, err := c.watchPods(ctx, namespace, selector)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		go func() {
+			for ev := range events {
+				m.handle(ev)
+			}
+			m.wg.Wait()
+			close(m.in)
+		}()
+	}
+
+	go func() {
+		defer cancel()
+		m.run()
+	}()
+	return m.out, nil
+}
+
+// workloadSelector 返回工作负载的 pod label selector 以及用于过滤 pod 的 owner UID;
+// Deployment 的 pod 属于 ReplicaSet, 只按 selector 过滤, 与 DeploymentPods 一致
+func (c *clientSetClient) workloadSelector(ctx context.Context, namespace, kind, name string) (string, types.UID, error) {
+	var (
+		selector *metav1.LabelSelector
+		uid      types.UID
+	)
+	switch strings.ToLower(kind) {
+	case "deployment":
+		deploy, err := c.DeploymentGetContext(ctx, namespace, name)
+		if err != nil {
+			return "", "", err
+		}
+		selector = deploy.Spec.Selector
+	case "statefulset":
+		sts, err := c.StatefulSetGetContext(ctx, namespace, name)
+		if err != nil {
+			return "", "", err
+		}
+		selector, uid = sts.Spec.Selector, sts.UID
+	case "daemonset":
+		ds, err := c.DaemonSetGetContext(ctx, namespace, name)
+		if err != nil {
+			return "", "", err
+		}
+		selector, uid = ds.Spec.Selector, ds.UID
+	case "job":
+		job, err := c.JobGetContext(ctx, namespace, name)
+		if err != nil {
+			return "", "", err
+		}
+		selector, uid = job.Spec.Selector, job.UID
+	default:
+		e := NewError(ReasonInvalid, kind, namespace, name,
+			fmt.Errorf("unsupported workload kind %q, must be one of Deployment, StatefulSet, DaemonSet, Job", kind))
+		e.Cluster = c.Cluster
+		return "", "", e
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		e := NewError(ReasonInvalid, kind, namespace, name, err)
+		e.Cluster = c.Cluster
+		return "", "", e
+	}
+	return s.String(), uid, nil
+}
+
+// logMerger 管理所有 pod 日志流, 并在 run 中按时间戳合并输出
+type logMerger struct {
+	c         *clientSetClient
+	ctx       context.Context
+	namespace string
+	opts      PodLogOpts
+	ownerUID  types.UID
+	startTime time.Time
+
+	in  chan LogLine
+	out chan LogLine
+	wg  sync.WaitGroup
+
+	// attached 与 pods 只在 attach/handle 中访问, 它们在同一个 goroutine 中执行
+	attached map[string]string // pod UID/容器 -> 正在读取的 containerID
+	pods     map[types.UID]podLogContext
+}
+
+// podLogContext pod 所有日志流共用的 ctx, pod 删除时取消
+type podLogContext struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (m *logMerger) handle(ev PodWatchEvent) {
+	switch ev.Type {
+	case watch.Error:
+		m.send(LogLine{Err: ev.Err})
+	case watch.Deleted:
+		if p, ok := m.pods[ev.Pod.UID]; ok {
+			p.cancel()
+			delete(m.pods, ev.Pod.UID)
+		}
+		prefix := string(ev.Pod.UID) + "/"
+		for key := range m.attached {
+			if strings.HasPrefix(key, prefix) {
+				delete(m.attached, key)
+			}
+		}
+	case watch.Added, watch.Modified:
+		m.attach(ev.Pod)
+	}
+}
+
+// attach 为 pod 中尚未读取的容器打开日志流; 容器重启后 containerID 变化, 会重新接入
+func (m *logMerger) attach(pod *apiv1.Pod) {
+	if m.ownerUID != "" {
+		if ref := metav1.GetControllerOf(pod); ref == nil || ref.UID != m.ownerUID {
+			return
+		}
+	}
+	statuses := pod.Status.ContainerStatuses
+	if m.opts.Container != "" {
+		statuses = append(append([]apiv1.ContainerStatus{}, pod.Status.InitContainerStatuses...), statuses...)
+	}
+	for _, status := range statuses {
+		if m.opts.Container != "" && status.Name != m.opts.Container {
+			continue
+		}
+		var startedAt time.Time
+		switch {
+		case status.State.Running != nil:
+			startedAt = status.State.Running.StartedAt.Time
+		case status.State.Terminated != nil:
+			startedAt = status.State.Terminated.StartedAt.Time
+		default:
+			// 容器尚未启动, 等待后续 Modified 事件
+			continue
+		}
+		key := string(pod.UID) + "/" + status.Name
+		if id, ok := m.attached[key]; ok && id == status.ContainerID {
+			continue
+		}
+		m.attached[key] = status.ContainerID
+
+		opts := m.opts
+		opts.Container = status.Name
+		opts.Timestamps = true
+		if m.opts.Follow && startedAt.After(m.startTime) {
+			// 开始跟踪之后才启动的容器, 从头读取
+			opts.TailLines, opts.SinceSeconds, opts.SinceTime = 0, 0, time.Time{}
+		}
+		p, ok := m.pods[pod.UID]
+		if !ok {
+			p.ctx, p.cancel = context.WithCancel(m.ctx)
+			m.pods[pod.UID] = p
+		}
+		m.wg.Add(1)
+		go m.stream(p.ctx, pod.Name, opts)
+	}
+}
+
+func (m *logMerger) stream(ctx context.Context, podName string, opts PodLogOpts) {
+	defer m.wg.Done()
+	rc, err := m.c.PodLogsWithOptsContext(ctx, m.namespace, podName, opts)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.send(LogLine{Pod: podName, Container: opts.Container, Err: err})
+		}
+		return
+	}
+	defer rc.Close()
+	reader := bufio.NewReader(rc)
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimRight(line, "\r\n"); line != "" {
+			l := LogLine{Pod: podName, Container: opts.Container}
+			l.Time, l.Line = parseLogTimestamp(line)
+			if !m.send(l) {
+				return
+			}
+		}
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				m.send(LogLine{Pod: podName, Container: opts.Container, Err: err})
+			}
+			return
+		}
+	}
+}
+
+func (m *logMerger) send(l LogLine) bool {
+	select {
+	case m.in <- l:
+		return true
+	case <-m.ctx.Done():
+		return false
+	}
+}
+
+// parseLogTimestamp 拆分 kubelet 添加的 RFC3339Nano 时间戳, 无法解析时使用当前时间
+func parseLogTimestamp(line string) (time.Time, string) {
+	if i := strings.IndexByte(line, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, line[:i]); err == nil {
+			return t, line[i+1:]
+		}
+	}
+	return time.Now(), line
+}
+
+// run 将收到的日志放入按时间排序的堆中, 收到超过 logMergeWindow 的日志才输出, in 关闭后输出剩余日志并关闭 out
+func (m *logMerger) run() {
+	defer close(m.out)
+	ticker := time.NewTicker(logMergeWindow / 4)
+	defer ticker.Stop()
+	buffer := &logHeap{}
+	var seq int64
+	flush := func(all bool) bool {
+		deadline := time.Now().Add(-logMergeWindow)
+		for buffer.Len() > 0 {
+			if !all && (*buffer)[0].received.After(deadline) {
+				return true
+			}
+			item := heap.Pop(buffer).(logItem)
+			select {
+			case m.out <- item.line:
+			case <-m.ctx.Done():
+				return false
+			}
+		}
+		return true
+	}
+	for {
+		select {
+		case l, ok := <-m.in:
+			if !ok {
+				flush(true)
+				return
+			}
+			seq++
+			heap.Push(buffer, logItem{line: l, received: time.Now(), seq: seq})
+		case <-ticker.C:
+			if !flush(false) {
+				return
+			}
+		case <-m.ctx.Done():
+			return
+		}
+	}
+}
+
+type logItem struct {
+	line     LogLine
+	received time.Time
+	seq      int64
+}
+
+// logHeap 按日志时间排序, 时间相同时保持接收顺序
+type logHeap []logItem
+
+func (h logHeap) Len() int { return len(h) }
+func (h logHeap) Less(i, j int) bool {
+	if !h[i].line.Time.Equal(h[j].line.Time) {
+		return h[i].line.Time.Before(h[j].line.Time)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h logHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *logHeap) Push(x interface{}) { *h = append(*h, x.(logItem)) }
+func (h *logHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestWorkloadLogs(t *testing.T) {
+	window := logMergeWindow
+	logMergeWindow = 20 * time.Millisecond
+	t.Cleanup(func() { logMergeWindow = window })
+	isController := true
+	labels := map[string]string{"app": "mysql"}
+	pod := func(name string, owner types.UID) *apiv1.Pod {
+		return &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				UID:             types.UID(name),
+				Labels:          labels,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "mysql", UID: owner, Controller: &isController}},
+			},
+			Spec: apiv1.PodSpec{Containers: []apiv1.Container{{Name: "mysql"}, {Name: "exporter"}}},
+			Status: apiv1.PodStatus{
+				InitContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "init-schema", ContainerID: "containerd://0", State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{}}},
+				},
+				ContainerStatuses: []apiv1.ContainerStatus{
+					{Name: "mysql", ContainerID: "containerd://1", State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}}},
+					{Name: "exporter", State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+				},
+			},
+		}
+	}
+	c := NewFakeClientSet(
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "default", UID: "sts-uid"},
+			Spec:       appsv1.StatefulSetSpec{Selector: &metav1.LabelSelector{MatchLabels: labels}},
+		},
+		pod("mysql-0", "sts-uid"),
+		pod("mysql-1", "sts-uid"),
+		pod("orphan", "other-uid"),
+	)
+
+	ch, err := c.WorkloadLogs("default", "StatefulSet", "mysql", PodLogOpts{TailLines: 10})
+	assert.NoError(t, err)
+	var lines []string
+	for l := range ch {
+		lines = append(lines, l.String())
+	}
+	assert.ElementsMatch(t, []string{"[mysql-0/mysql] fake logs", "[mysql-1/mysql] fake logs"}, lines)
+
+	// 指定 init 容器
+	ch, err = c.WorkloadLogs("default", "StatefulSet", "mysql", PodLogOpts{Container: "init-schema"})
+	assert.NoError(t, err)
+	lines = nil
+	for l := range ch {
+		lines = append(lines, l.String())
+	}
+	assert.ElementsMatch(t, []string{"[mysql-0/init-schema] fake logs", "[mysql-1/init-schema] fake logs"}, lines)
+
+	// follow 时自动接入新创建的 pod
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err = c.WorkloadLogsContext(ctx, "default", "statefulset", "mysql", PodLogOpts{Follow: true, Container: "mysql"})
+	assert.NoError(t, err)
+	received := map[string]bool{}
+	for len(received) < 2 {
+		received[(<-ch).Pod] = true
+	}
+	_, err = c.ClientSet.CoreV1().Pods("default").Create(context.Background(), pod("mysql-2", "sts-uid"), metav1.CreateOptions{})
+	assert.NoError(t, err)
+	select {
+	case l := <-ch:
+		assert.EqualValues(t, "mysql-2", l.Pod)
+	case <-time.After(5 * time.Second):
+		t.Fatal("new pod not attached")
+	}
+	cancel()
+	for range ch {
+	}
+
+	_, err = c.WorkloadLogs("default", "ReplicaSet", "mysql", PodLogOpts{})
+	assert.True(t, IsInvalid(err))
+	_, err = c.WorkloadLogs("default", "Deployment", "mysql", PodLogOpts{})
+	assert.True(t, IsNotFound(err))
+}
+
+func TestLogMergerOrder(t *testing.T) {
+	window := logMergeWindow
+	logMergeWindow = 50 * time.Millisecond
+	t.Cleanup(func() { logMergeWindow = window })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m := &logMerger{ctx: ctx, in: make(chan LogLine, 10), out: make(chan LogLine)}
+	go m.run()
+
+	base := time.Date(2022, 4, 10, 8, 0, 0, 0, time.UTC)
+	for _, raw := range []string{
+		base.Add(2*time.Second).Format(time.RFC3339Nano) + " third",
+		base.Format(time.RFC3339Nano) + " first",
+		base.Add(time.Second).Format(time.RFC3339Nano) + " second",
+	} {
+		l := LogLine{Pod: "p", Container: "c"}
+		l.Time, l.Line = parseLogTimestamp(raw)
+		m.in <- l
+	}
+	close(m.in)
+	var lines []string
+	for l := range m.out {
+		lines = append(lines, l.Line)
+	}
+	assert.EqualValues(t, []string{"first", "second", "third"}, lines)
+}